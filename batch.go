@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+)
+
+// BatchResult 批量执行中单组事实的结果，Index 为该组事实在输入中的下标
+type BatchResult struct {
+	Index      int
+	FiredRules []string
+	Err        error
+}
+
+// newKnowledgeBaseInstance 从知识库蓝图创建一个新的实例
+// 知识库加载和克隆会读写 KnowledgeLibrary，这里串行化以便在多个 goroutine 中调用
+func (executor *RuleExecutor) newKnowledgeBaseInstance() *ast.KnowledgeBase {
+	executor.libraryLock.Lock()
+	defer executor.libraryLock.Unlock()
+	return executor.knowledgeLibrary.NewKnowledgeBaseInstance(executor.ruleName, executor.ruleVersion)
+}
+
+// ExecuteBatch 使用 workers 个 goroutine 并行执行多组事实，结果顺序与输入一致
+// 每个 goroutine 从实例池取出独立的知识库实例，结束后归还；各组事实不能共享指针，注册的监听器需要是并发安全的
+// workers <= 0 时使用 GOMAXPROCS 个 goroutine
+func (executor *RuleExecutor) ExecuteBatch(batch []Facts, workers int) []BatchResult {
+	results := make([]BatchResult, len(batch))
+	if len(batch) == 0 {
+		return results
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(batch) {
+		workers = len(batch)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			knowledgeBase := executor.instances.Get()
+			defer executor.instances.Put(knowledgeBase)
+			for index := range indexes {
+				results[index] = executor.executeBatchItem(knowledgeBase, index, batch[index])
+			}
+		}()
+	}
+	for index := range batch {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// executeBatchItem 在指定的知识库实例上执行一组事实，规则执行中的 panic 转换为该组事实的错误
+func (executor *RuleExecutor) executeBatchItem(knowledgeBase *ast.KnowledgeBase, index int, facts Facts) (result BatchResult) {
+	result.Index = index
+	defer func() {
+		if r := recover(); r != nil {
+			result.Err = fmt.Errorf("执行规则失败: %v", r)
+		}
+	}()
+
+	recorder := &firedRuleRecorder{}
+	result.Err = executor.execute(executor.tracingEngine(recorder), knowledgeBase, facts)
+	result.FiredRules = recorder.firedRules
+	return result
+}
+
+// MonitorResult 批量评估中单个集群的结果
+type MonitorResult struct {
+	Index      int
+	Monitor    *TiDBMonitor
+	FiredRules []string
+	Findings   []Finding
+	Err        error
+}
+
+// EvaluateBatch 并行评估多个集群的监控数据，结果顺序与输入一致，单个集群失败不影响其他集群
+// 监控数据需要已经调用过 CalculateStatistics，且不能在多个下标之间共享
+func (executor *TiDBRuleExecutor) EvaluateBatch(monitors []*TiDBMonitor, workers int) []MonitorResult {
+	batch := make([]Facts, len(monitors))
+	for i, monitor := range monitors {
+		batch[i] = executor.Facts(monitor)
+	}
+
+	results := make([]MonitorResult, len(monitors))
+	for i, batchResult := range executor.ExecuteBatch(batch, workers) {
+		results[i] = MonitorResult{
+			Index:      batchResult.Index,
+			Monitor:    monitors[i],
+			FiredRules: batchResult.FiredRules,
+			Err:        batchResult.Err,
+		}
+		if batchResult.Err == nil {
+			results[i].Findings = monitors[i].Findings()
+		}
+	}
+	return results
+}
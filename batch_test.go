@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateBatch(t *testing.T) {
+	scenarios, err := LoadScenarios("scenarios")
+	if err != nil {
+		t.Fatalf("加载场景失败: %v", err)
+	}
+
+	// 每个场景复制多份，模拟几十个集群
+	var monitors, expected []*TiDBMonitor
+	for i := 0; i < 5; i++ {
+		for _, scenario := range scenarios {
+			monitors = append(monitors, scenario.BuildMonitor())
+			expected = append(expected, scenario.BuildMonitor())
+		}
+	}
+	// 中间插入一个空的监控数据，只影响自己的结果
+	monitors = append(monitors[:3], append([]*TiDBMonitor{nil}, monitors[3:]...)...)
+	expected = append(expected[:3], append([]*TiDBMonitor{nil}, expected[3:]...)...)
+
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	results := executor.EvaluateBatch(monitors, 4)
+	if len(results) != len(monitors) {
+		t.Fatalf("期望 %d 个结果，实际 %d 个", len(monitors), len(results))
+	}
+
+	sequential, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	for i, result := range results {
+		if result.Index != i || result.Monitor != monitors[i] {
+			t.Fatalf("结果 %d 的顺序不正确", i)
+		}
+		if expected[i] == nil {
+			if result.Err == nil || !strings.Contains(result.Err.Error(), "缺少事实") {
+				t.Errorf("结果 %d: 空监控数据应返回缺少事实的错误，实际 %v", i, result.Err)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("结果 %d: 执行失败: %v", i, result.Err)
+			continue
+		}
+
+		firedRules, err := sequential.ExecuteWithTrace(expected[i])
+		if err != nil {
+			t.Fatalf("顺序执行失败: %v", err)
+		}
+		// 相同优先级的规则触发顺序不固定，只比较触发的规则集合
+		if !reflect.DeepEqual(sortedRules(result.FiredRules), sortedRules(firedRules)) || !reflect.DeepEqual(result.Findings, expected[i].Findings()) {
+			t.Errorf("结果 %d: 并行结果 %v %v 与顺序结果 %v %v 不一致",
+				i, result.FiredRules, result.Findings, firedRules, expected[i].Findings())
+		}
+	}
+}
+
+func TestExecuteBatchEmpty(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	if results := executor.EvaluateBatch(nil, 0); len(results) != 0 {
+		t.Errorf("空输入应返回空结果，实际 %v", results)
+	}
+}
+
+func sortedRules(rules []string) []string {
+	sorted := append([]string{}, rules...)
+	sort.Strings(sorted)
+	return sorted
+}
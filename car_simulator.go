@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TestCar 测试车辆
+type TestCar struct {
+	SpeedUp        bool
+	SpeedDown      bool
+	Speed          float64
+	MaxSpeed       float64
+	SpeedIncrement float64
+}
+
+// DistanceRecord 距离记录，跨 tick 累计
+type DistanceRecord struct {
+	TotalDistance float64
+	LastSpeed     float64
+}
+
+// 驾驶动作
+const (
+	CarAccelerate = "accelerate"
+	CarBrake      = "brake"
+	CarCoast      = "coast"
+)
+
+// CarPhase 驾驶计划中的一个阶段：连续 Ticks 个 tick 执行同一个动作
+type CarPhase struct {
+	Action string `json:"action"`
+	Ticks  int    `json:"ticks"`
+}
+
+// CarDrivingPlan 驾驶计划，超出计划长度的 tick 按 coast 处理
+type CarDrivingPlan []CarPhase
+
+// ParseCarDrivingPlan 解析驾驶计划，格式为 accelerate:10,coast:3,brake:5
+func ParseCarDrivingPlan(text string) (CarDrivingPlan, error) {
+	var plan CarDrivingPlan
+	for _, item := range splitList(text) {
+		action, ticksText, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("驾驶计划 %q 格式错误，应为 动作:tick数", item)
+		}
+		switch action {
+		case CarAccelerate, CarBrake, CarCoast:
+		default:
+			return nil, fmt.Errorf("未知的驾驶动作 %q（可选: accelerate, brake, coast）", action)
+		}
+		ticks, err := strconv.Atoi(ticksText)
+		if err != nil || ticks <= 0 {
+			return nil, fmt.Errorf("驾驶计划 %q 的 tick 数无效", item)
+		}
+		plan = append(plan, CarPhase{Action: action, Ticks: ticks})
+	}
+	if len(plan) == 0 {
+		return nil, fmt.Errorf("驾驶计划为空")
+	}
+	return plan, nil
+}
+
+// Ticks 计划的总 tick 数
+func (plan CarDrivingPlan) Ticks() int {
+	total := 0
+	for _, phase := range plan {
+		total += phase.Ticks
+	}
+	return total
+}
+
+// ActionAt 第 tick 个 tick（从 1 开始）的驾驶动作
+func (plan CarDrivingPlan) ActionAt(tick int) string {
+	for _, phase := range plan {
+		if tick <= phase.Ticks {
+			return phase.Action
+		}
+		tick -= phase.Ticks
+	}
+	return CarCoast
+}
+
+// CarSimulationStep 一个 tick 执行后的状态
+type CarSimulationStep struct {
+	Tick          int      `json:"tick"`
+	Action        string   `json:"action"`
+	Speed         float64  `json:"speed"`
+	TotalDistance float64  `json:"total_distance"`
+	LastSpeed     float64  `json:"last_speed"`
+	FiredRules    []string `json:"fired_rules"`
+}
+
+// CarSimulator 基于 rules.grl 的多步车辆模拟器，每个 tick 执行一次规则，TestCar 和 DistanceRecord 的状态跨 tick 保留
+type CarSimulator struct {
+	executor *RuleExecutor
+}
+
+// NewCarSimulator 创建车辆模拟器，ruleSet 为空时使用内置的 car 规则包
+func NewCarSimulator(ruleSet *RuleSet) (*CarSimulator, error) {
+	if ruleSet == nil {
+		ruleSet = &RuleSet{Packs: []string{"car"}}
+	}
+	executor, err := NewRuleExecutor(ruleSet, "CarSimulation", "1.0.0", "TestCar", "DistanceRecord")
+	if err != nil {
+		return nil, err
+	}
+	return &CarSimulator{executor: executor}, nil
+}
+
+// Run 按驾驶计划模拟 steps 个 tick，返回每个 tick 的状态时间线
+func (simulator *CarSimulator) Run(car *TestCar, record *DistanceRecord, plan CarDrivingPlan, steps int) ([]*CarSimulationStep, error) {
+	timeline := make([]*CarSimulationStep, 0, steps)
+	for tick := 1; tick <= steps; tick++ {
+		action := plan.ActionAt(tick)
+		car.SpeedUp = action == CarAccelerate
+		car.SpeedDown = action == CarBrake
+
+		firedRules, err := simulator.executor.ExecuteWithTrace(Facts{"TestCar": car, "DistanceRecord": record})
+		if err != nil {
+			return timeline, fmt.Errorf("第 %d 个 tick 执行规则失败: %v", tick, err)
+		}
+
+		timeline = append(timeline, &CarSimulationStep{
+			Tick:          tick,
+			Action:        action,
+			Speed:         car.Speed,
+			TotalDistance: record.TotalDistance,
+			LastSpeed:     record.LastSpeed,
+			FiredRules:    firedRules,
+		})
+	}
+	return timeline, nil
+}
+
+// WriteCarTimelineCSV 以 CSV 输出时间线，触发的规则用 | 分隔
+func WriteCarTimelineCSV(writer io.Writer, timeline []*CarSimulationStep) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write([]string{"tick", "action", "speed", "total_distance", "last_speed", "fired_rules"}); err != nil {
+		return err
+	}
+	for _, step := range timeline {
+		err := csvWriter.Write([]string{
+			strconv.Itoa(step.Tick),
+			step.Action,
+			strconv.FormatFloat(step.Speed, 'f', -1, 64),
+			strconv.FormatFloat(step.TotalDistance, 'f', -1, 64),
+			strconv.FormatFloat(step.LastSpeed, 'f', -1, 64),
+			strings.Join(step.FiredRules, "|"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// WriteCarTimelineJSON 以 JSON 输出时间线
+func WriteCarTimelineJSON(writer io.Writer, timeline []*CarSimulationStep) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(timeline)
+}
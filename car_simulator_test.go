@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCarSimulator(t *testing.T) {
+	simulator, err := NewCarSimulator(nil)
+	if err != nil {
+		t.Fatalf("初始化车辆模拟器失败: %v", err)
+	}
+
+	plan, err := ParseCarDrivingPlan("accelerate:4,coast:1,brake:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	car := &TestCar{MaxSpeed: 30, SpeedIncrement: 10}
+	record := &DistanceRecord{}
+	timeline, err := simulator.Run(car, record, plan, plan.Ticks()+1)
+	if err != nil {
+		t.Fatalf("模拟失败: %v", err)
+	}
+
+	// 每个 tick 先调整速度，再按调整后的速度累计距离；滑行时保持速度继续累计，超出计划的 tick 按 coast 处理
+	expected := []struct {
+		action   string
+		speed    float64
+		distance float64
+		fired    string
+	}{
+		{CarAccelerate, 10, 10, "SpeedUp|RecordDistance"},
+		{CarAccelerate, 20, 30, "SpeedUp|RecordDistance"},
+		{CarAccelerate, 30, 60, "SpeedUp|RecordDistance"},
+		{CarAccelerate, 30, 90, "RecordDistance"},
+		{CarCoast, 30, 120, "RecordDistance"},
+		{CarBrake, 20, 140, "SpeedDown|RecordDistance"},
+		{CarBrake, 10, 150, "SpeedDown|RecordDistance"},
+		{CarCoast, 10, 160, "RecordDistance"},
+	}
+	if len(timeline) != len(expected) {
+		t.Fatalf("期望 %d 个 tick，实际 %d 个", len(expected), len(timeline))
+	}
+	for i, want := range expected {
+		step := timeline[i]
+		if step.Tick != i+1 || step.Action != want.action || step.Speed != want.speed ||
+			step.TotalDistance != want.distance || strings.Join(step.FiredRules, "|") != want.fired {
+			t.Errorf("tick %d: 期望 %+v，实际 %+v", i+1, want, step)
+		}
+	}
+	if record.TotalDistance != 160 || record.LastSpeed != 10 {
+		t.Errorf("最终距离记录不符: %+v", record)
+	}
+
+	var csvOut bytes.Buffer
+	if err := WriteCarTimelineCSV(&csvOut, timeline); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(timeline)+1 || rows[3][2] != "30" || rows[3][5] != "SpeedUp|RecordDistance" {
+		t.Errorf("CSV 输出不符: %v", rows)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := WriteCarTimelineJSON(&jsonOut, timeline); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []CarSimulationStep
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(timeline) || decoded[4].TotalDistance != 120 {
+		t.Errorf("JSON 输出不符: %+v", decoded)
+	}
+}
+
+func TestCarSimulatorClampsSpeed(t *testing.T) {
+	simulator, err := NewCarSimulator(nil)
+	if err != nil {
+		t.Fatalf("初始化车辆模拟器失败: %v", err)
+	}
+
+	// 最大速度不是加速度的整数倍：加速越过最大速度时限速，刹车越过 0 时停车，距离按限制后的速度累计
+	plan, err := ParseCarDrivingPlan("accelerate:3,coast:2,brake:3,coast:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	car := &TestCar{MaxSpeed: 25, SpeedIncrement: 10}
+	record := &DistanceRecord{}
+	timeline, err := simulator.Run(car, record, plan, plan.Ticks())
+	if err != nil {
+		t.Fatalf("模拟失败: %v", err)
+	}
+
+	expected := []struct {
+		speed    float64
+		distance float64
+		fired    string
+	}{
+		{10, 10, "SpeedUp|RecordDistance"},
+		{20, 30, "SpeedUp|RecordDistance"},
+		{25, 55, "SpeedUp|MaxSpeedReached|RecordDistance"},
+		{25, 80, "RecordDistance"},
+		{25, 105, "RecordDistance"},
+		{15, 120, "SpeedDown|RecordDistance"},
+		{5, 125, "SpeedDown|RecordDistance"},
+		{0, 125, "SpeedDown|Stopped|RecordDistance"},
+		{0, 125, "RecordDistance"},
+	}
+	if len(timeline) != len(expected) {
+		t.Fatalf("期望 %d 个 tick，实际 %d 个", len(expected), len(timeline))
+	}
+	for i, want := range expected {
+		step := timeline[i]
+		if step.Speed != want.speed || step.TotalDistance != want.distance || strings.Join(step.FiredRules, "|") != want.fired {
+			t.Errorf("tick %d: 期望 %+v，实际 %+v", i+1, want, step)
+		}
+	}
+}
+
+func TestParseCarDrivingPlan(t *testing.T) {
+	for _, text := range []string{"", "accelerate", "fly:3", "brake:0", "coast:x"} {
+		if _, err := ParseCarDrivingPlan(text); err == nil {
+			t.Errorf("驾驶计划 %q 应解析失败", text)
+		}
+	}
+}
@@ -0,0 +1,769 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// runCommand 执行 grule-diag 子命令，返回进程退出码
+func runCommand(name string, args []string) int {
+	switch name {
+	case "test":
+		return runTestCommand(args)
+	case "shadow":
+		return runShadowCommand(args)
+	case "packs":
+		return runPacksCommand(args)
+	case "simulate":
+		return runSimulateCommand(args)
+	case "slowlog":
+		return runSlowLogCommand(args)
+	case "grafana":
+		return runGrafanaCommand(args)
+	case "report":
+		return runReportCommand(args)
+	case "table":
+		return runTableCommand(args)
+	case "convert":
+		return runConvertCommand(args)
+	case "history":
+		return runHistoryCommand(args)
+	case "replay":
+		return runReplayCommand(args)
+	case "help", "-h", "--help":
+		printUsage()
+		return 0
+	default:
+		fmt.Fprint(os.Stderr, T("cmd.unknown_command", name))
+		printUsage()
+		return 2
+	}
+}
+
+// printUsage 输出命令行帮助
+func printUsage() {
+	fmt.Fprint(os.Stderr, T("cmd.usage"))
+}
+
+// runTestCommand 运行场景测试: grule-diag test [-pack tidb-hotspot] [-rules extra.grl] scenarios/
+func runTestCommand(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	ruleSet := addRuleSetFlags(flags)
+	ruleName := flags.String("name", "TiDBHotspot", T("cmd.flag.name"))
+	ruleVersion := flags.String("version", "1.0.0", T("cmd.flag.version"))
+	verbose := flags.Bool("v", false, T("cmd.test.flag.verbose"))
+	coverage := flags.Bool("coverage", false, T("cmd.test.flag.coverage"))
+	coverageOut := flags.String("coverage-out", "", T("cmd.test.flag.coverage_out"))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, T("cmd.test.usage"))
+		return 2
+	}
+
+	var scenarios []*Scenario
+	for _, path := range flags.Args() {
+		loaded, err := LoadScenarios(path)
+		if err != nil {
+			fmt.Fprint(os.Stderr, T("cmd.load_scenarios_failed", err))
+			return 1
+		}
+		scenarios = append(scenarios, loaded...)
+	}
+
+	runner := NewScenarioRunner(ruleSet(), *ruleName, *ruleVersion)
+	if *coverage || *coverageOut != "" {
+		runner.Coverage = NewCoverageRecorder()
+	}
+	results := runner.RunAll(scenarios)
+
+	failed := 0
+	for _, result := range results {
+		if result.Passed() {
+			fmt.Printf("PASS  %s\n", result.Scenario.Name)
+		} else {
+			failed++
+			fmt.Printf("FAIL  %s (%s)\n", result.Scenario.Name, result.Scenario.file)
+			if result.Err != nil {
+				fmt.Print(T("cmd.test.error", result.Err))
+			}
+			for _, failure := range result.Failures {
+				fmt.Printf("      %s\n", failure)
+			}
+		}
+		if *verbose {
+			fmt.Print(T("cmd.test.fired_rules", result.FiredRules))
+		}
+	}
+
+	fmt.Print(T("cmd.test.summary", len(results), len(results)-failed, failed))
+
+	if runner.Coverage != nil {
+		report := runner.Coverage.Report()
+		if *coverage {
+			fmt.Println()
+			report.WriteText(os.Stdout)
+		}
+		if *coverageOut != "" {
+			if err := writeCoverageFile(*coverageOut, report); err != nil {
+				fmt.Fprint(os.Stderr, T("cmd.test.coverage_failed", err))
+				return 1
+			}
+		}
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runShadowCommand 影子评估: grule-diag shadow -candidate-rules tidb-v2.grl -candidate-version 1.1.0 scenarios/
+func runShadowCommand(args []string) int {
+	flags := flag.NewFlagSet("shadow", flag.ContinueOnError)
+	ruleSet := addRuleSetFlags(flags)
+	ruleName := flags.String("name", "TiDBHotspot", T("cmd.flag.name"))
+	ruleVersion := flags.String("version", "1.0.0", T("cmd.shadow.flag.version"))
+	candidateVersion := flags.String("candidate-version", "1.1.0", T("cmd.shadow.flag.candidate_version"))
+	candidatePacks := flags.String("candidate-pack", "", T("cmd.shadow.flag.candidate_pack"))
+	candidateRules := flags.String("candidate-rules", "", T("cmd.shadow.flag.candidate_rules"))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 || (*candidatePacks == "" && *candidateRules == "") {
+		fmt.Fprintln(os.Stderr, T("cmd.shadow.usage"))
+		return 2
+	}
+
+	active := ruleSet()
+	executor, err := NewTiDBRuleExecutorWithRuleSet(active, *ruleName, *ruleVersion)
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.init_executor_failed", err))
+		return 1
+	}
+	candidate := &RuleSet{
+		Packs:       splitList(*candidatePacks),
+		PackDirs:    active.PackDirs,
+		OverrideDir: active.OverrideDir,
+		Files:       splitList(*candidateRules),
+	}
+	if err := executor.LoadShadowVersion(*candidateVersion, candidate); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	var scenarios []*Scenario
+	for _, path := range flags.Args() {
+		loaded, err := LoadScenarios(path)
+		if err != nil {
+			fmt.Fprint(os.Stderr, T("cmd.load_scenarios_failed", err))
+			return 1
+		}
+		scenarios = append(scenarios, loaded...)
+	}
+
+	changed := 0
+	for _, scenario := range scenarios {
+		result, err := executor.ExecuteWithShadow(scenario.BuildMonitor())
+		if err != nil {
+			fmt.Fprint(os.Stderr, T("cmd.shadow.execute_failed", scenario.Name, err))
+			return 1
+		}
+		switch {
+		case result.CandidateErr != nil:
+			changed++
+			fmt.Print(T("cmd.shadow.candidate_failed", scenario.Name, result.CandidateErr))
+		case result.HasDiff():
+			changed++
+			fmt.Printf("DIFF  %s\n", scenario.Name)
+			for _, diff := range result.Diffs {
+				fmt.Printf("      %s\n", diff)
+			}
+		default:
+			fmt.Printf("SAME  %s\n", scenario.Name)
+		}
+	}
+
+	fmt.Print(T("cmd.shadow.summary",
+		*ruleName, *ruleVersion, *ruleName, *candidateVersion, len(scenarios), changed))
+	return 0
+}
+
+// addRuleSetFlags 注册规则来源相关的命令行参数，返回的函数在参数解析后构造规则集合
+func addRuleSetFlags(flags *flag.FlagSet) func() *RuleSet {
+	return addRuleSetFlagsWithPack(flags, DefaultRulePack)
+}
+
+// addRuleSetFlagsWithPack 与 addRuleSetFlags 相同，-pack 的默认值为 defaultPack
+func addRuleSetFlagsWithPack(flags *flag.FlagSet, defaultPack string) func() *RuleSet {
+	packs := flags.String("pack", defaultPack, T("cmd.flag.pack"))
+	packDirs := flags.String("pack-dir", "", T("cmd.flag.pack_dir"))
+	rules := flags.String("rules", "", T("cmd.flag.rules"))
+	rulesDir := flags.String("rules-dir", "", T("cmd.flag.rules_dir"))
+	return func() *RuleSet {
+		return &RuleSet{
+			Packs:       splitList(*packs),
+			PackDirs:    splitList(*packDirs),
+			OverrideDir: *rulesDir,
+			Files:       splitList(*rules),
+		}
+	}
+}
+
+// splitList 拆分逗号分隔的参数，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runPacksCommand 列出可用的规则包: grule-diag packs [-pack-dir dir]
+func runPacksCommand(args []string) int {
+	flags := flag.NewFlagSet("packs", flag.ContinueOnError)
+	packDirs := flags.String("pack-dir", "", T("cmd.flag.pack_dir"))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	registry, err := (&RuleSet{PackDirs: splitList(*packDirs)}).Registry()
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.packs.load_failed", err))
+		return 1
+	}
+	for _, manifest := range registry.Packs() {
+		fmt.Printf("%-24s %-10s %s\n", manifest.Ref(), manifest.Location(), manifest.LocalizedDescription(""))
+		if len(manifest.DependsOn) > 0 {
+			fmt.Print(T("cmd.packs.depends_on", strings.Join(manifest.DependsOn, ", ")))
+		}
+		if len(manifest.RequiredFacts) > 0 {
+			fmt.Print(T("cmd.packs.required_facts", strings.Join(manifest.RequiredFacts, ", ")))
+		}
+	}
+	return 0
+}
+
+// runSimulateCommand 车辆模拟: grule-diag simulate -plan accelerate:10,coast:3,brake:5 -format csv
+func runSimulateCommand(args []string) int {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	planText := flags.String("plan", "accelerate:10,coast:3,brake:5", T("cmd.simulate.flag.plan"))
+	steps := flags.Int("steps", 0, T("cmd.simulate.flag.steps"))
+	speed := flags.Float64("speed", 0, T("cmd.simulate.flag.speed"))
+	maxSpeed := flags.Float64("max-speed", 100, T("cmd.simulate.flag.max_speed"))
+	increment := flags.Float64("increment", 10, T("cmd.simulate.flag.increment"))
+	format := flags.String("format", "csv", T("cmd.simulate.flag.format"))
+	output := flags.String("o", "", T("cmd.flag.output"))
+	packs := flags.String("pack", "car", T("cmd.simulate.flag.pack"))
+	rules := flags.String("rules", "", T("cmd.flag.rules"))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "csv" && *format != "json" {
+		fmt.Fprint(os.Stderr, T("cmd.unsupported_format", *format))
+		return 2
+	}
+
+	plan, err := ParseCarDrivingPlan(*planText)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	if *steps <= 0 {
+		*steps = plan.Ticks()
+	}
+
+	simulator, err := NewCarSimulator(&RuleSet{Packs: splitList(*packs), Files: splitList(*rules)})
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.simulate.init_failed", err))
+		return 1
+	}
+	car := &TestCar{Speed: *speed, MaxSpeed: *maxSpeed, SpeedIncrement: *increment}
+	timeline, err := simulator.Run(car, &DistanceRecord{}, plan, *steps)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	writer := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprint(os.Stderr, T("cmd.create_output_failed", err))
+			return 1
+		}
+		defer file.Close()
+		writer = file
+	}
+	if *format == "json" {
+		err = WriteCarTimelineJSON(writer, timeline)
+	} else {
+		err = WriteCarTimelineCSV(writer, timeline)
+	}
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.simulate.timeline_failed", err))
+		return 1
+	}
+	return 0
+}
+
+// runSlowLogCommand 聚合慢查询: grule-diag slowlog [-top 10] [-store 10.0.1.3:20160] tidb_slow_query.log
+func runSlowLogCommand(args []string) int {
+	flags := flag.NewFlagSet("slowlog", flag.ContinueOnError)
+	top := flags.Int("top", 10, T("cmd.slowlog.flag.top"))
+	store := flags.String("store", "", T("cmd.slowlog.flag.store"))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, T("cmd.slowlog.usage"))
+		return 2
+	}
+
+	var queries []*SlowQuery
+	for _, path := range flags.Args() {
+		loaded, err := LoadSlowLogFile(path)
+		if err != nil {
+			fmt.Fprint(os.Stderr, T("cmd.slowlog.parse_failed", path, err))
+			return 1
+		}
+		queries = append(queries, loaded...)
+	}
+
+	digests := AggregateSlowQueries(queries)
+	if *store != "" {
+		digests = TopDigestsForStore(digests, *top, *store)
+	} else if len(digests) > *top {
+		digests = digests[:*top]
+	}
+
+	fmt.Print(T("cmd.slowlog.total", len(queries)))
+	if *store != "" {
+		fmt.Println(T("cmd.slowlog.estimate_note"))
+	}
+	for _, digest := range digests {
+		fmt.Print(T("cmd.slowlog.digest",
+			digest.Digest, digest.ExecCount, digest.TotalQueryTime, digest.MaxQueryTime, digest.ProcessKeys, digest.CopTasks))
+		if *store != "" {
+			fmt.Print(T("cmd.slowlog.store_keys", *store, digest.StoreKeys[*store]))
+		}
+		fmt.Printf("    %s\n", digest.Query)
+	}
+	return 0
+}
+
+// runGrafanaCommand 离线评估 Grafana 快照: grule-diag grafana [-pack tidb-hotspot] [-workers 4] tikv_details_snapshot.json
+func runGrafanaCommand(args []string) int {
+	flags := flag.NewFlagSet("grafana", flag.ContinueOnError)
+	ruleSet := addRuleSetFlags(flags)
+	ruleName := flags.String("name", "TiDBHotspot", T("cmd.flag.name"))
+	ruleVersion := flags.String("version", "1.0.0", T("cmd.flag.version"))
+	workers := flags.Int("workers", 0, T("cmd.flag.workers"))
+	verbose := flags.Bool("v", false, T("cmd.grafana.flag.verbose"))
+	history := addHistoryFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, T("cmd.grafana.usage"))
+		return 2
+	}
+
+	samples, err := LoadGrafanaSnapshotFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.grafana.import_failed", err))
+		return 1
+	}
+	executor, err := NewTiDBRuleExecutorWithRuleSet(ruleSet(), *ruleName, *ruleVersion)
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.init_executor_failed", err))
+		return 1
+	}
+
+	monitors := make([]*TiDBMonitor, len(samples))
+	for i, sample := range samples {
+		monitors[i] = sample.Monitor
+	}
+	failed := 0
+	withFindings := 0
+	var records []*IncidentRecord
+	for i, result := range executor.EvaluateBatch(monitors, *workers) {
+		timestamp := samples[i].Time.Format(time.RFC3339)
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Printf("%s  ERR  %v\n", timestamp, result.Err)
+		case len(result.Findings) > 0:
+			withFindings++
+			for _, finding := range result.Findings {
+				fmt.Printf("%s  %s\n", timestamp, finding)
+			}
+		case *verbose:
+			fmt.Printf("%s  -\n", timestamp)
+		}
+		if result.Err == nil {
+			records = append(records, executor.NewIncidentRecord(history.clusterID, samples[i].Time, result.Monitor, result.FiredRules, nil))
+		}
+	}
+	fmt.Print(T("cmd.grafana.summary", len(samples), withFindings))
+	if err := history.record(records); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runReportCommand 生成诊断报告: grule-diag report -format html -o report.html scenarios/write_hotspot.yaml
+func runReportCommand(args []string) int {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	ruleSet := addRuleSetFlags(flags)
+	ruleName := flags.String("name", "TiDBHotspot", T("cmd.flag.name"))
+	ruleVersion := flags.String("version", "1.0.0", T("cmd.flag.version"))
+	format := flags.String("format", ReportFormatMarkdown, T("cmd.report.flag.format"))
+	output := flags.String("o", "", T("cmd.flag.output"))
+	history := addHistoryFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, T("cmd.report.usage"))
+		return 2
+	}
+
+	scenario, err := LoadScenarioFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	runner := NewScenarioRunner(ruleSet(), *ruleName, *ruleVersion)
+	executor, err := runner.executorFor(scenario)
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.init_executor_failed", err))
+		return 1
+	}
+	nodeExecutor, err := runner.nodeExecutorFor(scenario)
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.init_node_executor_failed", err))
+		return 1
+	}
+	monitor := scenario.BuildMonitor()
+	report, err := executor.DiagnoseWithNodes(monitor, nodeExecutor)
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.report.execute_failed", err))
+		return 1
+	}
+	report.Title += ": " + scenario.Name
+
+	firedRules := make([]string, 0, len(report.Rules))
+	for _, rule := range report.Rules {
+		firedRules = append(firedRules, rule.Name)
+	}
+	if err := history.record([]*IncidentRecord{executor.NewIncidentRecord(history.clusterID, report.GeneratedAt, monitor, firedRules, nodeExecutor)}); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	writer := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprint(os.Stderr, T("cmd.create_output_failed", err))
+			return 1
+		}
+		defer file.Close()
+		writer = file
+	}
+	if err := report.Write(writer, *format); err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.report.write_failed", err))
+		return 1
+	}
+	return 0
+}
+
+// runTableCommand 编译决策表: grule-diag table [-o rules.grl] tidb_shard_row_id_bits.table.yaml
+func runTableCommand(args []string) int {
+	flags := flag.NewFlagSet("table", flag.ContinueOnError)
+	output := flags.String("o", "", T("cmd.flag.output"))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, T("cmd.table.usage"))
+		return 2
+	}
+
+	var grl []string
+	for _, path := range flags.Args() {
+		table, err := LoadDecisionTableFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		compiled, err := table.Compile()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		grl = append(grl, compiled)
+	}
+
+	if *output == "" {
+		fmt.Print(strings.Join(grl, "\n"))
+		return 0
+	}
+	if err := os.WriteFile(*output, []byte(strings.Join(grl, "\n")), 0644); err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.write_file_failed", *output, err))
+		return 1
+	}
+	return 0
+}
+
+// runConvertCommand 转换规则格式: grule-diag convert -format yaml -o tidb.rules.yaml tidb.grl
+func runConvertCommand(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	format := flags.String("format", RuleFormatYAML, T("cmd.convert.flag.format"))
+	output := flags.String("o", "", T("cmd.flag.output"))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, T("cmd.convert.usage"))
+		return 2
+	}
+
+	var rules []*RuleDefinition
+	for _, path := range flags.Args() {
+		loaded, err := LoadRulesAsDefinitions(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		rules = append(rules, loaded...)
+	}
+	data, err := MarshalRuleDefinitions(rules, *format)
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.convert.failed", err))
+		return 1
+	}
+
+	if *output == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.write_file_failed", *output, err))
+		return 1
+	}
+	return 0
+}
+
+// writeCoverageFile 将覆盖率报告写入 JSON 文件
+func writeCoverageFile(path string, report *CoverageReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return report.WriteJSON(file)
+}
+
+// historyFlags 将评估结果写入事件历史的命令行参数
+type historyFlags struct {
+	path      string
+	clusterID string
+}
+
+// addHistoryFlags 注册 -history / -cluster 参数
+func addHistoryFlags(flags *flag.FlagSet) *historyFlags {
+	history := &historyFlags{}
+	flags.StringVar(&history.path, "history", "", T("cmd.flag.history"))
+	flags.StringVar(&history.clusterID, "cluster", DefaultClusterID, T("cmd.flag.history_cluster"))
+	return history
+}
+
+// record 未指定 -history 时不做任何事
+func (history *historyFlags) record(records []*IncidentRecord) error {
+	if history.path == "" {
+		return nil
+	}
+	store, err := OpenIncidentStore(history.path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if err := store.Record(records...); err != nil {
+		return errors.New(T("cmd.history.record_failed", err))
+	}
+	return nil
+}
+
+// runHistoryCommand 查询事件历史: grule-diag history -db incidents.db -cluster prod -node tikv-3 -from 2024-05-01 -to 2024-05-02
+func runHistoryCommand(args []string) int {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	dbPath := flags.String("db", "", T("cmd.flag.db"))
+	clusterID := flags.String("cluster", "", T("cmd.history.flag.cluster"))
+	node := flags.String("node", "", T("cmd.history.flag.node"))
+	table := flags.String("table", "", T("cmd.history.flag.table"))
+	types := flags.String("type", "", T("cmd.history.flag.type"))
+	from := flags.String("from", "", T("cmd.flag.from"))
+	to := flags.String("to", "", T("cmd.flag.to"))
+	records := flags.Bool("records", false, T("cmd.history.flag.records"))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *dbPath == "" || flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, T("cmd.history.usage"))
+		return 2
+	}
+
+	query := IncidentQuery{ClusterID: *clusterID, Node: *node, Table: *table, Types: splitList(*types)}
+	var err error
+	if query.From, err = ParseHistoryTime(*from); err == nil {
+		query.To, err = ParseHistoryTime(*to)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	store, err := OpenIncidentStore(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	if *records {
+		found, err := store.Query(query)
+		if err != nil {
+			fmt.Fprint(os.Stderr, T("cmd.query_history_failed", err))
+			return 1
+		}
+		for _, record := range found {
+			findings := make([]string, 0, len(record.Findings))
+			for _, finding := range record.Findings {
+				findings = append(findings, finding.String())
+			}
+			fmt.Printf("%s  %-12s %s@%s  %s\n", record.Time.Format(time.RFC3339), record.ClusterID,
+				record.RuleName, record.RuleVersion, strings.Join(findings, ", "))
+		}
+		fmt.Print(T("cmd.history.records_total", len(found)))
+		return 0
+	}
+
+	episodes, err := store.Episodes(query)
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.query_history_failed", err))
+		return 1
+	}
+	for _, episode := range episodes {
+		end := episode.End.Format(time.RFC3339)
+		if episode.Ongoing {
+			end += T("cmd.history.ongoing")
+		}
+		fmt.Print(T("cmd.history.episode", episode.ClusterID, episode.Key(),
+			episode.Start.Format(time.RFC3339), end, episode.Evaluations, episode.MaxValue))
+		if len(episode.Tables) > 0 {
+			fmt.Print(T("cmd.history.episode_tables", strings.Join(episode.Tables, ", ")))
+		}
+		fmt.Println()
+	}
+	fmt.Print(T("cmd.history.episodes_total", len(episodes)))
+	return 0
+}
+
+// runReplayCommand 使用新版本规则回放历史快照: grule-diag replay -db incidents.db -from 2024-05-01 -to 2024-05-02 -pack tidb-hotspot -rules-dir rules-v2
+func runReplayCommand(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	ruleSet := addRuleSetFlagsWithPack(flags, "")
+	ruleName := flags.String("name", "TiDBHotspot", T("cmd.flag.name"))
+	ruleVersion := flags.String("version", "replay", T("cmd.replay.flag.version"))
+	nodePacks := flags.String("node-pack", "", T("cmd.replay.flag.node_pack"))
+	dbPath := flags.String("db", "", T("cmd.flag.db"))
+	clusterID := flags.String("cluster", "", T("cmd.replay.flag.cluster"))
+	from := flags.String("from", "", T("cmd.flag.from"))
+	to := flags.String("to", "", T("cmd.flag.to"))
+	workers := flags.Int("workers", 0, T("cmd.flag.workers"))
+	verbose := flags.Bool("v", false, T("cmd.replay.flag.verbose"))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	rules := ruleSet()
+	if *dbPath == "" || flags.NArg() != 0 || (len(rules.Packs) == 0 && len(rules.Files) == 0) {
+		fmt.Fprintln(os.Stderr, T("cmd.replay.usage"))
+		return 2
+	}
+
+	query := IncidentQuery{ClusterID: *clusterID}
+	var err error
+	if query.From, err = ParseHistoryTime(*from); err == nil {
+		query.To, err = ParseHistoryTime(*to)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	executor, err := NewTiDBRuleExecutorWithRuleSet(rules, *ruleName, *ruleVersion)
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.init_executor_failed", err))
+		return 1
+	}
+	var nodeExecutor *TiKVNodeRuleExecutor
+	if *nodePacks != "" {
+		nodeRules := &RuleSet{Packs: splitList(*nodePacks), PackDirs: rules.PackDirs, OverrideDir: rules.OverrideDir}
+		if nodeExecutor, err = NewTiKVNodeRuleExecutorWithRuleSet(nodeRules, *ruleName+"Node", *ruleVersion); err != nil {
+			fmt.Fprint(os.Stderr, T("cmd.init_node_executor_failed", err))
+			return 1
+		}
+	}
+
+	store, err := OpenIncidentStore(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	records, err := store.Query(query)
+	store.Close()
+	if err != nil {
+		fmt.Fprint(os.Stderr, T("cmd.query_history_failed", err))
+		return 1
+	}
+
+	results := executor.Replay(records, nodeExecutor, *workers)
+	for _, result := range results {
+		record := result.Record
+		header := fmt.Sprintf("%s  %-12s %s@%s", record.Time.Format(time.RFC3339), record.ClusterID, record.RuleName, record.RuleVersion)
+		switch {
+		case result.Err != nil:
+			fmt.Printf("ERR   %s: %v\n", header, result.Err)
+		case result.HasDiff():
+			fmt.Printf("DIFF  %s\n", header)
+			for _, diff := range result.Diffs {
+				fmt.Printf("      %s\n", diff)
+			}
+		case *verbose:
+			fmt.Printf("SAME  %s\n", header)
+		}
+	}
+
+	summary := SummarizeReplay(results)
+	fmt.Print(T("cmd.replay.summary",
+		summary.Records, summary.Changed, summary.Added, summary.Removed, summary.ChangedFindings, summary.Failed))
+	if summary.Ignored > 0 {
+		fmt.Print(T("cmd.replay.ignored", summary.Ignored))
+	}
+	if summary.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+)
+
+// CoverageRecorder 规则覆盖率记录器，作为引擎监听器统计规则评估、触发次数以及子条件的真假分支
+type CoverageRecorder struct {
+	mu    sync.Mutex
+	rules map[string]*RuleCoverage
+}
+
+// RuleCoverage 单条规则的覆盖率
+type RuleCoverage struct {
+	RuleName      string               `json:"rule_name"`
+	Salience      int                  `json:"salience"`
+	EvaluateCount int                  `json:"evaluate_count"`
+	FireCount     int                  `json:"fire_count"`
+	Conditions    []*ConditionCoverage `json:"conditions"`
+}
+
+// ConditionCoverage 规则 when 中单个子条件（&& / || 连接的操作数）的覆盖率
+type ConditionCoverage struct {
+	Text       string `json:"text"`
+	TrueCount  int    `json:"true_count"`
+	FalseCount int    `json:"false_count"`
+}
+
+// CoverageReport 覆盖率报告
+type CoverageReport struct {
+	Rules             []*RuleCoverage `json:"rules"`
+	FiredRules        int             `json:"fired_rules"`
+	TotalRules        int             `json:"total_rules"`
+	CoveredBranches   int             `json:"covered_branches"`
+	TotalBranches     int             `json:"total_branches"`
+	UnfiredRuleNames  []string        `json:"unfired_rule_names"`
+	RuleCoverageRatio float64         `json:"rule_coverage_ratio"`
+}
+
+// NewCoverageRecorder 创建覆盖率记录器
+func NewCoverageRecorder() *CoverageRecorder {
+	return &CoverageRecorder{
+		rules: make(map[string]*RuleCoverage),
+	}
+}
+
+// Register 登记知识库中的所有规则，保证从未被评估的规则也出现在报告中
+func (recorder *CoverageRecorder) Register(knowledgeBase *ast.KnowledgeBase) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	for _, entry := range knowledgeBase.RuleEntries {
+		recorder.ruleCoverage(entry)
+	}
+}
+
+// ruleCoverage 获取规则对应的覆盖率记录，不存在时创建（调用方需持有锁）
+func (recorder *CoverageRecorder) ruleCoverage(entry *ast.RuleEntry) *RuleCoverage {
+	coverage, ok := recorder.rules[entry.RuleName]
+	if ok {
+		return coverage
+	}
+
+	coverage = &RuleCoverage{
+		RuleName: entry.RuleName,
+		Salience: entry.Salience,
+	}
+	if entry.WhenScope != nil {
+		for _, condition := range collectConditions(entry.WhenScope.Expression, nil) {
+			coverage.Conditions = append(coverage.Conditions, &ConditionCoverage{Text: condition.GrlText})
+		}
+	}
+	recorder.rules[entry.RuleName] = coverage
+	return coverage
+}
+
+// collectConditions 展开 && / || 连接的表达式，返回所有子条件（按出现顺序）
+func collectConditions(expression *ast.Expression, conditions []*ast.Expression) []*ast.Expression {
+	if expression == nil {
+		return conditions
+	}
+	if expression.LeftExpression != nil && expression.RightExpression != nil &&
+		(expression.Operator == ast.OpAnd || expression.Operator == ast.OpOr) {
+		conditions = collectConditions(expression.LeftExpression, conditions)
+		return collectConditions(expression.RightExpression, conditions)
+	}
+	// 括号包裹的子表达式继续展开
+	if expression.SingleExpression != nil && !expression.Negated {
+		return collectConditions(expression.SingleExpression, conditions)
+	}
+	return append(conditions, expression)
+}
+
+// BeginCycle 实现 engine.GruleEngineListener
+func (recorder *CoverageRecorder) BeginCycle(cycle uint64) {}
+
+// EvaluateRuleEntry 实现 engine.GruleEngineListener，记录本次评估中各子条件的取值
+func (recorder *CoverageRecorder) EvaluateRuleEntry(cycle uint64, entry *ast.RuleEntry, candidate bool) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	coverage := recorder.ruleCoverage(entry)
+	coverage.EvaluateCount++
+	// 评估出错时根表达式没有取值，子条件的取值不可信
+	if entry.WhenScope == nil || !entry.WhenScope.Expression.Evaluated {
+		return
+	}
+	coverage.recordConditions(entry.WhenScope.Expression, 0)
+}
+
+// recordConditions 从根表达式开始按短路规则统计子条件的取值，index 为 expression 中第一个子条件的下标，返回下一个子条件的下标。
+// 相同的表达式在 WorkingMemory 中是共享的，其 Evaluated / Value 可能来自其他规则，因此 && 的左侧为假、|| 的左侧为真时，
+// 右侧的子条件没有参与本条规则的评估，不计入真假分支
+func (coverage *RuleCoverage) recordConditions(expression *ast.Expression, index int) int {
+	if expression.LeftExpression != nil && expression.RightExpression != nil &&
+		(expression.Operator == ast.OpAnd || expression.Operator == ast.OpOr) {
+		index = coverage.recordConditions(expression.LeftExpression, index)
+		if left, ok := conditionValue(expression.LeftExpression); !ok || left == (expression.Operator == ast.OpOr) {
+			return index + len(collectConditions(expression.RightExpression, nil))
+		}
+		return coverage.recordConditions(expression.RightExpression, index)
+	}
+	if expression.SingleExpression != nil && !expression.Negated {
+		return coverage.recordConditions(expression.SingleExpression, index)
+	}
+	if value, ok := conditionValue(expression); ok && index < len(coverage.Conditions) {
+		if value {
+			coverage.Conditions[index].TrueCount++
+		} else {
+			coverage.Conditions[index].FalseCount++
+		}
+	}
+	return index + 1
+}
+
+// conditionValue 已评估的布尔表达式的取值
+func conditionValue(expression *ast.Expression) (bool, bool) {
+	if !expression.Evaluated || !expression.Value.IsValid() || expression.Value.Kind() != reflect.Bool {
+		return false, false
+	}
+	return expression.Value.Bool(), true
+}
+
+// ExecuteRuleEntry 实现 engine.GruleEngineListener
+func (recorder *CoverageRecorder) ExecuteRuleEntry(cycle uint64, entry *ast.RuleEntry) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.ruleCoverage(entry).FireCount++
+}
+
+// Report 生成覆盖率报告，规则按名称排序
+func (recorder *CoverageRecorder) Report() *CoverageReport {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	report := &CoverageReport{}
+	for _, coverage := range recorder.rules {
+		copied := *coverage
+		copied.Conditions = make([]*ConditionCoverage, 0, len(coverage.Conditions))
+		for _, condition := range coverage.Conditions {
+			conditionCopy := *condition
+			copied.Conditions = append(copied.Conditions, &conditionCopy)
+
+			report.TotalBranches += 2
+			if condition.TrueCount > 0 {
+				report.CoveredBranches++
+			}
+			if condition.FalseCount > 0 {
+				report.CoveredBranches++
+			}
+		}
+		report.Rules = append(report.Rules, &copied)
+
+		report.TotalRules++
+		if coverage.FireCount > 0 {
+			report.FiredRules++
+		} else {
+			report.UnfiredRuleNames = append(report.UnfiredRuleNames, coverage.RuleName)
+		}
+	}
+
+	sort.Slice(report.Rules, func(i, j int) bool {
+		return report.Rules[i].RuleName < report.Rules[j].RuleName
+	})
+	sort.Strings(report.UnfiredRuleNames)
+	if report.TotalRules > 0 {
+		report.RuleCoverageRatio = float64(report.FiredRules) / float64(report.TotalRules)
+	}
+	return report
+}
+
+// WriteText 以文本形式输出覆盖率报告
+func (report *CoverageReport) WriteText(writer io.Writer) {
+	branchRatio := 0.0
+	if report.TotalBranches > 0 {
+		branchRatio = float64(report.CoveredBranches) / float64(report.TotalBranches)
+	}
+	fmt.Fprint(writer, T("cmd.coverage.summary",
+		report.FiredRules, report.TotalRules, report.RuleCoverageRatio*100,
+		report.CoveredBranches, report.TotalBranches, branchRatio*100))
+
+	for _, rule := range report.Rules {
+		mark := "✓"
+		if rule.FireCount == 0 {
+			mark = "✗"
+		}
+		fmt.Fprint(writer, T("cmd.coverage.rule",
+			mark, rule.RuleName, rule.Salience, rule.EvaluateCount, rule.FireCount))
+		for _, condition := range rule.Conditions {
+			fmt.Fprint(writer, T("cmd.coverage.condition",
+				branchMark(condition.TrueCount), branchMark(condition.FalseCount), condition.Text))
+		}
+	}
+}
+
+// WriteJSON 以 JSON 形式输出覆盖率报告
+func (report *CoverageReport) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(report)
+}
+
+// branchMark 分支是否覆盖的标记
+func branchMark(count int) string {
+	if count > 0 {
+		return fmt.Sprintf("✓%d", count)
+	}
+	return "✗"
+}
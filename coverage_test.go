@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 场景集合需要覆盖 tidb.grl 中的所有规则
+func TestScenarioRuleCoverage(t *testing.T) {
+	scenarios, err := LoadScenarios("scenarios")
+	if err != nil {
+		t.Fatalf("加载场景失败: %v", err)
+	}
+
+	runner := NewScenarioRunner(&RuleSet{Packs: []string{DefaultRulePack}}, "TiDBHotspot", "1.0.0")
+	runner.Coverage = NewCoverageRecorder()
+	for _, result := range runner.RunAll(scenarios) {
+		if result.Err != nil {
+			t.Fatalf("执行场景 %s 失败: %v", result.Scenario.Name, result.Err)
+		}
+	}
+
+	report := runner.Coverage.Report()
+	if report.TotalRules == 0 {
+		t.Fatalf("覆盖率报告中没有规则")
+	}
+	if len(report.UnfiredRuleNames) > 0 {
+		t.Errorf("以下规则未被任何场景触发: %v", report.UnfiredRuleNames)
+	}
+}
+
+func TestCoverageRecorderConditions(t *testing.T) {
+	executor, err := NewTiDBRuleExecutor("tidb.grl", "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	recorder := NewCoverageRecorder()
+	executor.EnableCoverage(recorder)
+
+	monitor := &TiDBMonitor{
+		CheckWriteHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 30.0},
+			{NodeID: "tikv-2", RaftstoreCPU: 30.0},
+			{NodeID: "tikv-3", RaftstoreCPU: 90.0},
+		},
+	}
+	monitor.CalculateStatistics()
+	if err := executor.Execute(monitor); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+
+	var detect *RuleCoverage
+	for _, rule := range recorder.Report().Rules {
+		if rule.RuleName == "DetectWriteHotspot" {
+			detect = rule
+		}
+	}
+	if detect == nil {
+		t.Fatalf("覆盖率报告中缺少 DetectWriteHotspot")
+	}
+	if detect.FireCount != 1 {
+		t.Errorf("DetectWriteHotspot 期望触发 1 次，实际 %d 次", detect.FireCount)
+	}
+	if len(detect.Conditions) != 4 {
+		t.Fatalf("DetectWriteHotspot 期望 4 个子条件，实际 %d 个", len(detect.Conditions))
+	}
+	if detect.Conditions[3].TrueCount == 0 {
+		t.Errorf("热点比例条件应至少为真一次: %+v", detect.Conditions[3])
+	}
+}
+
+func TestCoverageRecorderSharedCondition(t *testing.T) {
+	// 两条规则共享子条件 MaxRaftstoreCPU > 50：First 评估后该表达式在 WorkingMemory 中已有取值，
+	// Second 的第一个条件为假时短路，共享的子条件不应计入 Second 的分支
+	grl := `rule First "读热点检查开启时标记 CPU 较高" salience 10 {
+    when
+        TiDBMonitor.CheckReadHotspot == true && TiDBMonitor.MaxRaftstoreCPU > 50
+    then
+        TiDBMonitor.ReadHotspotNode = "first";
+        Retract("First");
+}
+
+rule Second "写热点检查开启时标记 CPU 较高" salience 5 {
+    when
+        TiDBMonitor.CheckWriteHotspot == true && TiDBMonitor.MaxRaftstoreCPU > 50
+    then
+        TiDBMonitor.WriteHotspotNode = "second";
+        Retract("Second");
+}
+`
+	ruleFile := filepath.Join(t.TempDir(), "shared.grl")
+	if err := os.WriteFile(ruleFile, []byte(grl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	executor, err := NewTiDBRuleExecutor(ruleFile, "Shared", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	recorder := NewCoverageRecorder()
+	executor.EnableCoverage(recorder)
+
+	monitor := &TiDBMonitor{CheckReadHotspot: true, MaxRaftstoreCPU: 90}
+	if err := executor.Execute(monitor); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+
+	rules := make(map[string]*RuleCoverage)
+	for _, rule := range recorder.Report().Rules {
+		rules[rule.RuleName] = rule
+	}
+	// 第 1 轮 First 触发并撤回，第 2 轮只评估 Second
+	for name, want := range map[string][][2]int{
+		"First":  {{1, 0}, {1, 0}},
+		"Second": {{0, 2}, {0, 0}},
+	} {
+		rule := rules[name]
+		if rule == nil || len(rule.Conditions) != len(want) {
+			t.Fatalf("%s 的覆盖率不符: %+v", name, rule)
+		}
+		for i, counts := range want {
+			if condition := rule.Conditions[i]; condition.TrueCount != counts[0] || condition.FalseCount != counts[1] {
+				t.Errorf("%s 的子条件 %s: 真 %d 次、假 %d 次，期望真 %d 次、假 %d 次",
+					name, condition.Text, condition.TrueCount, condition.FalseCount, counts[0], counts[1])
+			}
+		}
+	}
+}
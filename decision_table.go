@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperjumptech/grule-rule-engine/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// decisionTableSuffixes 决策表文件的后缀，规则包和规则集合中的这类文件会被编译为 GRL 后加载
+var decisionTableSuffixes = []string{".table.yaml", ".table.yml", ".table.json"}
+
+// 决策表行的保留列
+const (
+	decisionColumnRule        = "rule"
+	decisionColumnPriority    = "priority"
+	decisionColumnDescription = "description"
+)
+
+// 决策表单元格的值类型
+const (
+	DecisionValueAuto       = ""           // 数值和 true / false 原样输出，其他按字符串加引号
+	DecisionValueString     = "string"     // 总是按字符串加引号
+	DecisionValueExpression = "expression" // 原样输出，可以引用其他事实字段
+)
+
+// decisionOperators 条件列支持的比较运算符
+var decisionOperators = map[string]bool{"==": true, "!=": true, ">": true, ">=": true, "<": true, "<=": true}
+
+// grlIdentifier 规则名和列名的格式
+var grlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// DecisionTable 决策表：每一行生成一条 GRL 规则，SRE 可以把比例 -> 建议值这类映射当作表格维护，
+// 而不是手工展开成几乎相同的规则块：
+//
+//	name: RecommendShardRowIDBits
+//	when: [TiDBMonitor.WriteHotspotDetected == true]
+//	conditions: [{column: ratio_above, expression: TiDBMonitor.WriteHotspotRatio, operator: ">"}]
+//	actions: [{column: bits, target: TiDBMonitor.ShardRowIDBits}]
+//	then: ['TiDBMonitor.Message("msg.shard_row_id_bits.recommend", TiDBMonitor.ShardRowIDBits)']
+//	rows_file: shard_row_id_bits.csv
+//
+// 生成的规则名为 name 加上行的 rule 列，每条规则在 then 的最后撤回自身
+type DecisionTable struct {
+	Name        string              `json:"name" yaml:"name"`               // 规则名前缀
+	Description string              `json:"description" yaml:"description"` // 行没有 description 时使用，{列名} 会被替换为该行的值
+	Priority    int                 `json:"priority" yaml:"priority"`       // 行没有 priority 时使用的 salience
+	When        []string            `json:"when" yaml:"when"`               // 所有行共有的条件，在条件列之前
+	Conditions  []DecisionCondition `json:"conditions" yaml:"conditions"`   // 条件列
+	Actions     []DecisionAction    `json:"actions" yaml:"actions"`         // 动作列
+	Then        []string            `json:"then" yaml:"then"`               // 所有行共有的动作，在动作列之后
+	Rows        []map[string]string `json:"rows" yaml:"rows"`               // 内联的行，与 rows_file 二选一
+	RowsFile    string              `json:"rows_file" yaml:"rows_file"`     // CSV 行文件，相对于决策表所在目录，第一行为列名
+
+	// 决策表来源，用于错误信息
+	location string
+}
+
+// DecisionCondition 条件列：单元格不为空时生成 expression operator 值
+type DecisionCondition struct {
+	Column     string `json:"column" yaml:"column"`
+	Expression string `json:"expression" yaml:"expression"`
+	Operator   string `json:"operator" yaml:"operator"`
+	Type       string `json:"type" yaml:"type"` // 值类型，见 DecisionValueAuto
+}
+
+// DecisionAction 动作列：单元格不为空时生成 target = 值
+type DecisionAction struct {
+	Column string `json:"column" yaml:"column"`
+	Target string `json:"target" yaml:"target"`
+	Type   string `json:"type" yaml:"type"` // 值类型，见 DecisionValueAuto
+}
+
+// isDecisionTableFile 文件是否为决策表
+func isDecisionTableFile(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range decisionTableSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadDecisionTableFile 加载磁盘上的决策表，rows_file 相对于决策表所在目录
+func LoadDecisionTableFile(path string) (*DecisionTable, error) {
+	return LoadDecisionTable(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
+
+// LoadDecisionTable 从 fsys 加载决策表（YAML / JSON），rows_file 相对于决策表所在目录
+func LoadDecisionTable(fsys fs.FS, name string) (*DecisionTable, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("读取决策表 %s 失败: %v", name, err)
+	}
+	table := &DecisionTable{location: name}
+	if err := yaml.Unmarshal(data, table); err != nil {
+		return nil, fmt.Errorf("解析决策表 %s 失败: %v", name, err)
+	}
+
+	if table.RowsFile != "" {
+		if len(table.Rows) > 0 {
+			return nil, fmt.Errorf("决策表 %s 不能同时指定 rows 和 rows_file", name)
+		}
+		rowsFile := path.Join(path.Dir(name), table.RowsFile)
+		data, err := fs.ReadFile(fsys, rowsFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取决策表 %s 的行文件失败: %v", name, err)
+		}
+		if table.Rows, err = parseDecisionRows(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("解析决策表 %s 的行文件 %s 失败: %v", name, table.RowsFile, err)
+		}
+	}
+	return table, nil
+}
+
+// parseDecisionRows 解析 CSV 行：第一行为列名，空行跳过
+func parseDecisionRows(reader io.Reader) ([]map[string]string, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comment = '#'
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("缺少列名")
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		empty := true
+		for i, value := range record {
+			if value = strings.TrimSpace(value); value != "" {
+				row[header[i]] = value
+				empty = false
+			}
+		}
+		if !empty {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// validate 校验决策表的列定义和行
+func (table *DecisionTable) validate() error {
+	if !grlIdentifier.MatchString(table.Name) {
+		return fmt.Errorf("决策表 %s: name %q 不是合法的规则名", table.location, table.Name)
+	}
+	if len(table.Rows) == 0 {
+		return fmt.Errorf("决策表 %s 没有行", table.location)
+	}
+
+	columns := map[string]bool{decisionColumnRule: true, decisionColumnPriority: true, decisionColumnDescription: true}
+	addColumn := func(column, kind string) error {
+		if !grlIdentifier.MatchString(column) {
+			return fmt.Errorf("决策表 %s: %s列名 %q 不合法", table.location, kind, column)
+		}
+		if columns[column] {
+			return fmt.Errorf("决策表 %s: 列 %s 重复或为保留列", table.location, column)
+		}
+		columns[column] = true
+		return nil
+	}
+	for _, condition := range table.Conditions {
+		if err := addColumn(condition.Column, "条件"); err != nil {
+			return err
+		}
+		if condition.Expression == "" || !decisionOperators[condition.Operator] {
+			return fmt.Errorf("决策表 %s: 条件列 %s 需要 expression 和比较运算符（==、!=、>、>=、<、<=）", table.location, condition.Column)
+		}
+		if err := checkDecisionValueType(condition.Type); err != nil {
+			return fmt.Errorf("决策表 %s: 条件列 %s: %v", table.location, condition.Column, err)
+		}
+	}
+	for _, action := range table.Actions {
+		if err := addColumn(action.Column, "动作"); err != nil {
+			return err
+		}
+		if action.Target == "" {
+			return fmt.Errorf("决策表 %s: 动作列 %s 缺少 target", table.location, action.Column)
+		}
+		if err := checkDecisionValueType(action.Type); err != nil {
+			return fmt.Errorf("决策表 %s: 动作列 %s: %v", table.location, action.Column, err)
+		}
+	}
+
+	ruleNames := make(map[string]bool, len(table.Rows))
+	for i, row := range table.Rows {
+		for column := range row {
+			if !columns[column] {
+				return fmt.Errorf("决策表 %s 第 %d 行: 未知的列 %s", table.location, i+1, column)
+			}
+		}
+		suffix := row[decisionColumnRule]
+		if suffix == "" || !grlIdentifier.MatchString(table.Name+suffix) {
+			return fmt.Errorf("决策表 %s 第 %d 行: rule 列 %q 不合法", table.location, i+1, suffix)
+		}
+		if ruleNames[suffix] {
+			return fmt.Errorf("决策表 %s 第 %d 行: 规则 %s 重复", table.location, i+1, table.Name+suffix)
+		}
+		ruleNames[suffix] = true
+		if priority := row[decisionColumnPriority]; priority != "" {
+			if _, err := strconv.Atoi(priority); err != nil {
+				return fmt.Errorf("决策表 %s 第 %d 行: priority %q 不是整数", table.location, i+1, priority)
+			}
+		}
+	}
+	return nil
+}
+
+// checkDecisionValueType 校验列的值类型
+func checkDecisionValueType(valueType string) error {
+	switch valueType {
+	case DecisionValueAuto, DecisionValueString, DecisionValueExpression:
+		return nil
+	}
+	return fmt.Errorf("未知的值类型 %q", valueType)
+}
+
+// decisionLiteral 按列的值类型把单元格转换为 GRL 表达式
+func decisionLiteral(value, valueType string) string {
+	switch valueType {
+	case DecisionValueExpression:
+		return value
+	case DecisionValueString:
+		return strconv.Quote(value)
+	}
+	if value == "true" || value == "false" {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// RuleNames 决策表生成的规则名，按行顺序排列
+func (table *DecisionTable) RuleNames() []string {
+	names := make([]string, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		names = append(names, table.Name+row[decisionColumnRule])
+	}
+	return names
+}
+
+// Compile 将决策表编译为 GRL，每行一条规则
+func (table *DecisionTable) Compile() (string, error) {
+	rules, err := table.Rules()
+	if err != nil {
+		return "", err
+	}
+	return RuleDefinitionsGRL(rules)
+}
+
+// Rules 将决策表展开为结构化规则定义，每行一条规则
+func (table *DecisionTable) Rules() ([]*RuleDefinition, error) {
+	if err := table.validate(); err != nil {
+		return nil, err
+	}
+
+	rules := make([]*RuleDefinition, 0, len(table.Rows))
+	for i, row := range table.Rows {
+		rule := &RuleDefinition{
+			Name:        table.Name + row[decisionColumnRule],
+			Description: table.describe(row),
+			Salience:    table.Priority,
+		}
+		if priority := row[decisionColumnPriority]; priority != "" {
+			rule.Salience, _ = strconv.Atoi(priority)
+		}
+
+		rule.When = append(rule.When, table.When...)
+		for _, condition := range table.Conditions {
+			if value, ok := row[condition.Column]; ok {
+				rule.When = append(rule.When, fmt.Sprintf("%s %s %s", condition.Expression, condition.Operator, decisionLiteral(value, condition.Type)))
+			}
+		}
+		if len(rule.When) == 0 {
+			return nil, fmt.Errorf("决策表 %s 第 %d 行: 规则 %s 没有任何条件", table.location, i+1, rule.Name)
+		}
+
+		for _, action := range table.Actions {
+			if value, ok := row[action.Column]; ok {
+				rule.Then = append(rule.Then, fmt.Sprintf("%s = %s", action.Target, decisionLiteral(value, action.Type)))
+			}
+		}
+		rule.Then = append(rule.Then, table.Then...)
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// describe 行的规则描述：优先使用 description 列，否则使用表的 description 并替换 {列名}
+func (table *DecisionTable) describe(row map[string]string) string {
+	if description := row[decisionColumnDescription]; description != "" {
+		return description
+	}
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	replacements := make([]string, 0, len(columns)*2)
+	for _, column := range columns {
+		replacements = append(replacements, "{"+column+"}", row[column])
+	}
+	return strings.NewReplacer(replacements...).Replace(table.Description)
+}
+
+// decisionTableResource 加载并编译决策表，返回可以加载到知识库的 GRL 资源
+func decisionTableResource(fsys fs.FS, name string) (pkg.Resource, error) {
+	table, err := LoadDecisionTable(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	grl, err := table.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return pkg.NewBytesResource([]byte(grl)), nil
+}
+
+// overrideFS 先在 overrideDir 中按文件名查找，不存在时使用 base，
+// 使覆盖目录既可以替换整个决策表，也可以只替换其中的 CSV 行文件
+type overrideFS struct {
+	base        fs.FS
+	overrideDir string
+}
+
+// Open 实现 fs.FS
+func (fsys overrideFS) Open(name string) (fs.File, error) {
+	if fsys.overrideDir != "" {
+		if file, err := os.Open(filepath.Join(fsys.overrideDir, path.Base(name))); err == nil {
+			return file, nil
+		}
+	}
+	return fsys.base.Open(name)
+}
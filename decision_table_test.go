@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDecisionTableCompile(t *testing.T) {
+	fsys := fstest.MapFS{"rules/ratio.table.yaml": {Data: []byte(`
+name: Ratio
+description: 比例超过 {above} 时级别为 {level}
+priority: 5
+when: [TiDBMonitor.CheckWriteHotspot == true]
+conditions:
+  - {column: above, expression: TiDBMonitor.WriteHotspotRatio, operator: ">"}
+  - {column: node, expression: TiDBMonitor.WriteHotspotNode, operator: "=="}
+  - {column: limit, expression: TiDBMonitor.WriteHotspotRatio, operator: "<=", type: expression}
+actions:
+  - {column: level, target: TiDBMonitor.TopWriteTables, type: string}
+  - {column: bits, target: TiDBMonitor.ShardRowIDBits}
+then: [TiDBMonitor.RecommendShardRowIDBits = true;]
+rows:
+  - {rule: High, above: 3.0, level: "15", bits: 15, priority: 9}
+  - {rule: Node, node: tikv-1, limit: TiDBMonitor.AvgRaftstoreCPU, level: low, description: 指定节点}
+`)}}
+
+	table, err := LoadDecisionTable(fsys, "rules/ratio.table.yaml")
+	if err != nil {
+		t.Fatalf("加载决策表失败: %v", err)
+	}
+	grl, err := table.Compile()
+	if err != nil {
+		t.Fatalf("编译决策表失败: %v", err)
+	}
+	want := `rule RatioHigh "比例超过 3.0 时级别为 15" salience 9 {
+    when
+        TiDBMonitor.CheckWriteHotspot == true &&
+        TiDBMonitor.WriteHotspotRatio > 3.0
+    then
+        TiDBMonitor.TopWriteTables = "15";
+        TiDBMonitor.ShardRowIDBits = 15;
+        TiDBMonitor.RecommendShardRowIDBits = true;
+        Retract("RatioHigh");
+}
+
+rule RatioNode "指定节点" salience 5 {
+    when
+        TiDBMonitor.CheckWriteHotspot == true &&
+        TiDBMonitor.WriteHotspotNode == "tikv-1" &&
+        TiDBMonitor.WriteHotspotRatio <= TiDBMonitor.AvgRaftstoreCPU
+    then
+        TiDBMonitor.TopWriteTables = "low";
+        TiDBMonitor.RecommendShardRowIDBits = true;
+        Retract("RatioNode");
+}
+`
+	if grl != want {
+		t.Errorf("生成的 GRL 不符:\n%s", grl)
+	}
+	if strings.Join(table.RuleNames(), ",") != "RatioHigh,RatioNode" {
+		t.Errorf("规则名不符: %v", table.RuleNames())
+	}
+
+	// 内置决策表生成的规则与原来手工展开的 RecommendShardRowIDBits* 一致
+	builtin, err := LoadDecisionTable(builtinRuleFS, "tidb_shard_row_id_bits.table.yaml")
+	if err != nil {
+		t.Fatalf("加载内置决策表失败: %v", err)
+	}
+	if got := strings.Join(builtin.RuleNames(), ","); got != "RecommendShardRowIDBitsHigh,RecommendShardRowIDBitsMedium,RecommendShardRowIDBitsLow,RecommendShardRowIDBitsMinimal" {
+		t.Errorf("内置决策表规则不符: %s", got)
+	}
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	if description := executor.RuleDescription("RecommendShardRowIDBitsMedium"); description != "检测到非聚簇索引写入热点（中），建议设置 SHARD_ROW_ID_BITS=12" {
+		t.Errorf("决策表规则描述不符: %s", description)
+	}
+}
+
+func TestDecisionTableErrors(t *testing.T) {
+	header := "name: T\nconditions: [{column: a, expression: TiDBMonitor.WriteHotspotRatio, operator: \">\"}]\n"
+	for name, table := range map[string]string{
+		"未知列":      header + "rows: [{rule: A, b: 1}]",
+		"规则重复":     header + "rows: [{rule: A, a: 1}, {rule: A, a: 2}]",
+		"缺少 rule":  header + "rows: [{a: 1}]",
+		"运算符":      "name: T\nconditions: [{column: a, expression: X, operator: \"=>\"}]\nrows: [{rule: A, a: 1}]",
+		"没有条件":     header + "rows: [{rule: A}]",
+		"priority": header + "rows: [{rule: A, a: 1, priority: high}]",
+		"没有行":      header,
+		"值类型":      "name: T\nactions: [{column: a, target: X, type: number}]\nrows: [{rule: A, a: 1}]",
+	} {
+		fsys := fstest.MapFS{"t.table.yaml": {Data: []byte(table)}}
+		loaded, err := LoadDecisionTable(fsys, "t.table.yaml")
+		if err == nil {
+			_, err = loaded.Compile()
+		}
+		if err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+
+	fsys := fstest.MapFS{
+		"t.table.yaml": {Data: []byte(header + "rows_file: rows.csv\nrows: [{rule: A, a: 1}]")},
+	}
+	if _, err := LoadDecisionTable(fsys, "t.table.yaml"); err == nil || !strings.Contains(err.Error(), "rows_file") {
+		t.Errorf("rows 和 rows_file 同时指定应返回错误: %v", err)
+	}
+}
+
+func TestDecisionTableOverride(t *testing.T) {
+	// 覆盖目录中只替换 CSV 行文件：比例超过 1.5 倍即建议 SHARD_ROW_ID_BITS=6
+	overrideDir := t.TempDir()
+	rows := "rule,ratio_above,bits,description\nMinimal,1.5,6,覆盖后的建议\n"
+	if err := os.WriteFile(filepath.Join(overrideDir, "tidb_shard_row_id_bits.csv"), []byte(rows), 0644); err != nil {
+		t.Fatal(err)
+	}
+	executor, err := NewTiDBRuleExecutorWithRuleSet(&RuleSet{Packs: []string{DefaultRulePack}, OverrideDir: overrideDir}, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	monitor := &TiDBMonitor{
+		CheckWriteHotspot:          true,
+		IsNonClusteredIndexHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 20},
+			{NodeID: "tikv-2", RaftstoreCPU: 20},
+			{NodeID: "tikv-3", RaftstoreCPU: 95},
+		},
+	}
+	monitor.CalculateStatistics()
+	firedRules, err := executor.ExecuteWithTrace(monitor)
+	if err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if !monitor.RecommendShardRowIDBits || monitor.ShardRowIDBits != 6 || executor.RuleDescription("RecommendShardRowIDBitsHigh") != "" {
+		t.Errorf("覆盖的决策表行未生效: %v bits=%d", firedRules, monitor.ShardRowIDBits)
+	}
+
+	// 磁盘上的决策表可以直接作为规则文件加载
+	tableFile := filepath.Join(overrideDir, "extra.table.yaml")
+	table := "name: Extra\nwhen: [TiDBMonitor.WriteHotspotDetected == true]\nactions: [{column: node, target: TiDBMonitor.TopWriteDigest}]\nrows: [{rule: Digest, node: hot}]\n"
+	if err := os.WriteFile(tableFile, []byte(table), 0644); err != nil {
+		t.Fatal(err)
+	}
+	executor, err = NewTiDBRuleExecutorWithFiles([]string{"tidb.grl", tableFile}, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	monitor.RecommendShardRowIDBits = false
+	if err := executor.Execute(monitor); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if monitor.TopWriteDigest != "hot" {
+		t.Errorf("磁盘决策表未加载: %q", monitor.TopWriteDigest)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 诊断结论类型
+const (
+	FindingWriteHotspot   = "write_hotspot"
+	FindingReadHotspot    = "read_hotspot"
+	FindingShardRowIDBits = "shard_row_id_bits"
+
+	FindingTiDBServerSkew           = "tidb_server_skew"
+	FindingTiDBServerMemoryPressure = "tidb_server_memory_pressure"
+	FindingTiDBServerOOMRisk        = "tidb_server_oom_risk"
+	FindingTiDBServerHighLatency    = "tidb_server_high_latency"
+
+	FindingThreadPoolSaturation = "tikv_thread_pool_saturation"
+
+	FindingZoneWriteImbalance   = "zone_write_imbalance"
+	FindingZoneReadImbalance    = "zone_read_imbalance"
+	FindingIsolatedWriteHotspot = "isolated_write_hotspot"
+	FindingSharedHostHotspot    = "shared_host_write_hotspot"
+
+	FindingInsertBatching   = "insert_batching"
+	FindingWriteKeyRedesign = "write_key_redesign"
+
+	// 节点级规则（tikv-node 规则包）通过 NodeResult.AddFinding 输出
+	FindingNodeCoprocessorOutlier = "node_coprocessor_outlier"
+	FindingNodeRaftstoreOutlier   = "node_raftstore_outlier"
+)
+
+// findingValueTolerance 比较结论数值时的容差
+const findingValueTolerance = 1e-6
+
+// Finding 规则执行后得到的诊断结论
+type Finding struct {
+	Type   string  `json:"type"`             // 结论类型，如 write_hotspot
+	Target string  `json:"target,omitempty"` // 结论对象，如节点 ID
+	Value  float64 `json:"value"`            // 主要指标，如热点比例或建议的 SHARD_ROW_ID_BITS
+
+	TopDigests []*SlowQueryDigest `json:"top_digests,omitempty"` // 读热点节点上处理 key 最多的 SQL（需要提供慢日志）
+}
+
+// Key 结论的唯一标识，用于不同规则版本之间的结论对比
+func (finding Finding) Key() string {
+	if finding.Target == "" {
+		return finding.Type
+	}
+	return finding.Type + "@" + finding.Target
+}
+
+// Title 结论类型的本地化名称（消息键 finding.<类型>），locale 为空时使用进程级默认语言
+func (finding Finding) Title(locale string) string {
+	return Translate(locale, "finding."+finding.Type)
+}
+
+// String 结论的文本形式
+func (finding Finding) String() string {
+	return fmt.Sprintf("%s=%.2f", finding.Key(), finding.Value)
+}
+
+// Findings 从规则执行结果中提取诊断结论
+func (monitor *TiDBMonitor) Findings() []Finding {
+	var findings []Finding
+	if monitor.WriteHotspotDetected {
+		findings = append(findings, Finding{Type: FindingWriteHotspot, Target: monitor.WriteHotspotNode, Value: monitor.WriteHotspotRatio})
+	}
+	if monitor.ReadHotspotDetected {
+		findings = append(findings, Finding{Type: FindingReadHotspot, Target: monitor.ReadHotspotNode, Value: monitor.ReadHotspotRatio, TopDigests: monitor.ReadHotspotDigests})
+	}
+	if monitor.RecommendShardRowIDBits {
+		findings = append(findings, Finding{Type: FindingShardRowIDBits, Target: monitor.WriteHotspotNode, Value: float64(monitor.ShardRowIDBits)})
+	}
+	if monitor.ZoneWriteImbalance {
+		findings = append(findings, Finding{Type: FindingZoneWriteImbalance, Target: monitor.HotWriteZone, Value: monitor.ZoneWriteImbalanceRatio})
+	}
+	if monitor.ZoneReadImbalance {
+		findings = append(findings, Finding{Type: FindingZoneReadImbalance, Target: monitor.HotReadZone, Value: monitor.ZoneReadImbalanceRatio})
+	}
+	if monitor.IsolatedWriteHotspot {
+		findings = append(findings, Finding{Type: FindingIsolatedWriteHotspot, Target: monitor.WriteHotspotNode, Value: monitor.IsolatedWriteHotspotRatio})
+	}
+	if monitor.SharedHostWriteHotspot {
+		findings = append(findings, Finding{Type: FindingSharedHostHotspot, Target: monitor.WriteHotspotHost, Value: float64(monitor.WriteHotspotHostStoreCount)})
+	}
+	if monitor.TiDBServerSkewDetected {
+		findings = append(findings, Finding{Type: FindingTiDBServerSkew, Target: monitor.TiDBServerSkewNode, Value: monitor.TiDBServerSkewRatio})
+	}
+	if monitor.TiDBServerOOMRisk {
+		findings = append(findings, Finding{Type: FindingTiDBServerOOMRisk, Target: monitor.TiDBServerMemoryNode, Value: monitor.MaxTiDBServerMemoryUsage})
+	} else if monitor.TiDBServerMemoryPressure {
+		findings = append(findings, Finding{Type: FindingTiDBServerMemoryPressure, Target: monitor.TiDBServerMemoryNode, Value: monitor.MaxTiDBServerMemoryUsage})
+	}
+	if monitor.TiDBServerHighLatency {
+		findings = append(findings, Finding{Type: FindingTiDBServerHighLatency, Target: monitor.TiDBServerDurationNode, Value: monitor.MaxTiDBServerDurationP99})
+	}
+	if monitor.RecommendInsertBatching {
+		findings = append(findings, Finding{Type: FindingInsertBatching, Target: monitor.TopWriteDigest, Value: monitor.TopWriteKeysShare})
+	}
+	if monitor.RecommendWriteKeyRedesign {
+		findings = append(findings, Finding{Type: FindingWriteKeyRedesign, Target: monitor.TopWriteDigest, Value: monitor.TopWriteKeysShare})
+	}
+	for _, pool := range monitor.SaturatedThreadPools() {
+		findings = append(findings, Finding{Type: FindingThreadPoolSaturation, Target: pool.Node + "/" + pool.Pool, Value: pool.Utilization})
+	}
+	return append(findings, monitor.NodeFindings()...)
+}
+
+// 结论差异类型
+const (
+	FindingAdded   = "added"
+	FindingRemoved = "removed"
+	FindingChanged = "changed"
+)
+
+// FindingDiff 两组结论之间的差异
+type FindingDiff struct {
+	Kind      string   `json:"kind"` // added / removed / changed
+	Key       string   `json:"key"`
+	Baseline  *Finding `json:"baseline,omitempty"`
+	Candidate *Finding `json:"candidate,omitempty"`
+}
+
+// String 差异的文本形式
+func (diff FindingDiff) String() string {
+	switch diff.Kind {
+	case FindingAdded:
+		return fmt.Sprintf("+ %s", diff.Candidate)
+	case FindingRemoved:
+		return fmt.Sprintf("- %s", diff.Baseline)
+	default:
+		return fmt.Sprintf("~ %s: %.2f -> %.2f", diff.Key, diff.Baseline.Value, diff.Candidate.Value)
+	}
+}
+
+// DiffFindings 对比基线结论与候选结论，按结论标识排序返回差异
+func DiffFindings(baseline, candidate []Finding) []FindingDiff {
+	baselineByKey := make(map[string]Finding, len(baseline))
+	for _, finding := range baseline {
+		baselineByKey[finding.Key()] = finding
+	}
+	candidateByKey := make(map[string]Finding, len(candidate))
+	for _, finding := range candidate {
+		candidateByKey[finding.Key()] = finding
+	}
+
+	var diffs []FindingDiff
+	for key, base := range baselineByKey {
+		base := base
+		cand, ok := candidateByKey[key]
+		if !ok {
+			diffs = append(diffs, FindingDiff{Kind: FindingRemoved, Key: key, Baseline: &base})
+			continue
+		}
+		if math.Abs(base.Value-cand.Value) > findingValueTolerance {
+			diffs = append(diffs, FindingDiff{Kind: FindingChanged, Key: key, Baseline: &base, Candidate: &cand})
+		}
+	}
+	for key, cand := range candidateByKey {
+		cand := cand
+		if _, ok := baselineByKey[key]; !ok {
+			diffs = append(diffs, FindingDiff{Kind: FindingAdded, Key: key, Candidate: &cand})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Grafana TiKV-Details 面板标题（大小写不敏感），面板数据对应 TiKVNode 的字段
+const (
+	grafanaRaftstoreCPUPanel   = "Raft store CPU"
+	grafanaCoprocessorCPUPanel = "Coprocessor CPU"
+)
+
+// MonitorSample 某一时刻的监控数据，时间序列中的一个点
+type MonitorSample struct {
+	Time    time.Time
+	Monitor *TiDBMonitor
+}
+
+// grafanaDashboard Grafana 快照中的 dashboard，只保留导入需要的字段
+type grafanaDashboard struct {
+	Title  string          `json:"title"`
+	Panels []*grafanaPanel `json:"panels"`
+	Rows   []struct {
+		Panels []*grafanaPanel `json:"panels"`
+	} `json:"rows"` // Grafana 5 之前的格式
+}
+
+// grafanaPanel 面板：旧格式的 snapshotData 为 {target, datapoints}，新格式为 data frame
+type grafanaPanel struct {
+	Title        string            `json:"title"`
+	Panels       []*grafanaPanel   `json:"panels"` // 折叠的 row 中的面板
+	SnapshotData []json.RawMessage `json:"snapshotData"`
+	FieldConfig  struct {
+		Defaults struct {
+			Unit string `json:"unit"`
+		} `json:"defaults"`
+	} `json:"fieldConfig"`
+	YAxes []struct {
+		Format string `json:"format"`
+	} `json:"yaxes"`
+}
+
+// grafanaLegacySeries 旧格式的时间序列：datapoints 为 [value, 毫秒时间戳]
+type grafanaLegacySeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][]*float64 `json:"datapoints"`
+}
+
+// grafanaDataFrame 新格式的 data frame：一个时间字段和若干数值字段
+type grafanaDataFrame struct {
+	Fields []struct {
+		Name   string            `json:"name"`
+		Type   string            `json:"type"`
+		Labels map[string]string `json:"labels"`
+		Config struct {
+			DisplayNameFromDS string `json:"displayNameFromDS"`
+		} `json:"config"`
+		Values []*float64 `json:"values"`
+	} `json:"fields"`
+}
+
+// grafanaSeries 某个实例在某个面板上的数据点（毫秒时间戳 -> 值）
+type grafanaSeries map[string]map[int64]float64
+
+// GrafanaSnapshot 解析后的 Grafana 快照：实例 -> 时间戳 -> Raftstore / Coprocessor CPU（%）
+type GrafanaSnapshot struct {
+	Title          string
+	RaftstoreCPU   grafanaSeries
+	CoprocessorCPU grafanaSeries
+}
+
+// ParseGrafanaSnapshot 解析 Grafana dashboard 快照导出（带 "dashboard" 外层或直接为 dashboard JSON），
+// 提取 "Raft store CPU" 和 "Coprocessor CPU" 面板每个实例的时间序列
+func ParseGrafanaSnapshot(reader io.Reader) (*GrafanaSnapshot, error) {
+	var document struct {
+		Dashboard *grafanaDashboard `json:"dashboard"`
+		grafanaDashboard
+	}
+	if err := json.NewDecoder(reader).Decode(&document); err != nil {
+		return nil, fmt.Errorf("解析 Grafana 快照失败: %v", err)
+	}
+	dashboard := document.Dashboard
+	if dashboard == nil {
+		dashboard = &document.grafanaDashboard
+	}
+
+	panels := dashboard.Panels
+	for _, row := range dashboard.Rows {
+		panels = append(panels, row.Panels...)
+	}
+
+	snapshot := &GrafanaSnapshot{Title: dashboard.Title}
+	for _, panel := range flattenGrafanaPanels(panels) {
+		var err error
+		switch {
+		case strings.EqualFold(strings.TrimSpace(panel.Title), grafanaRaftstoreCPUPanel):
+			snapshot.RaftstoreCPU, err = panel.series()
+		case strings.EqualFold(strings.TrimSpace(panel.Title), grafanaCoprocessorCPUPanel):
+			snapshot.CoprocessorCPU, err = panel.series()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("面板 %q: %v", panel.Title, err)
+		}
+	}
+	if snapshot.RaftstoreCPU == nil && snapshot.CoprocessorCPU == nil {
+		return nil, fmt.Errorf("Grafana 快照中没有 %q 或 %q 面板的数据", grafanaRaftstoreCPUPanel, grafanaCoprocessorCPUPanel)
+	}
+	return snapshot, nil
+}
+
+// LoadGrafanaSnapshotFile 加载 Grafana 快照文件并转换为监控数据时间序列
+func LoadGrafanaSnapshotFile(path string) ([]*MonitorSample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	snapshot, err := ParseGrafanaSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.MonitorSeries(), nil
+}
+
+// flattenGrafanaPanels 展开折叠 row 中的面板
+func flattenGrafanaPanels(panels []*grafanaPanel) []*grafanaPanel {
+	var flattened []*grafanaPanel
+	for _, panel := range panels {
+		flattened = append(flattened, panel)
+		flattened = append(flattened, flattenGrafanaPanels(panel.Panels)...)
+	}
+	return flattened
+}
+
+// scale 面板单位为 percentunit（1 表示一个核）时换算为百分比
+func (panel *grafanaPanel) scale() float64 {
+	unit := panel.FieldConfig.Defaults.Unit
+	if unit == "" && len(panel.YAxes) > 0 {
+		unit = panel.YAxes[0].Format
+	}
+	if unit == "percentunit" {
+		return 100
+	}
+	return 1
+}
+
+// series 解析面板的快照数据，同时支持旧格式和 data frame 格式
+func (panel *grafanaPanel) series() (grafanaSeries, error) {
+	scale := panel.scale()
+	series := make(grafanaSeries)
+	add := func(instance string, timestamp int64, value *float64) {
+		if instance == "" || value == nil {
+			return
+		}
+		if series[instance] == nil {
+			series[instance] = make(map[int64]float64)
+		}
+		series[instance][timestamp] = *value * scale
+	}
+
+	for _, raw := range panel.SnapshotData {
+		var probe map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &probe); err != nil {
+			return nil, fmt.Errorf("解析快照数据失败: %v", err)
+		}
+
+		if _, ok := probe["fields"]; ok {
+			var frame grafanaDataFrame
+			if err := json.Unmarshal(raw, &frame); err != nil {
+				return nil, fmt.Errorf("解析 data frame 失败: %v", err)
+			}
+			timeField := -1
+			for i, field := range frame.Fields {
+				if field.Type == "time" || strings.EqualFold(field.Name, "Time") {
+					timeField = i
+					break
+				}
+			}
+			if timeField < 0 {
+				return nil, fmt.Errorf("data frame 缺少时间字段")
+			}
+			times := frame.Fields[timeField].Values
+			for i, field := range frame.Fields {
+				if i == timeField {
+					continue
+				}
+				instance := field.Labels["instance"]
+				if instance == "" {
+					instance = field.Config.DisplayNameFromDS
+				}
+				if instance == "" {
+					instance = field.Name
+				}
+				for j, value := range field.Values {
+					if j < len(times) && times[j] != nil {
+						add(instance, int64(*times[j]), value)
+					}
+				}
+			}
+			continue
+		}
+
+		var legacy grafanaLegacySeries
+		if err := json.Unmarshal(raw, &legacy); err != nil {
+			return nil, fmt.Errorf("解析时间序列失败: %v", err)
+		}
+		for _, point := range legacy.Datapoints {
+			if len(point) != 2 || point[1] == nil {
+				return nil, fmt.Errorf("实例 %s 的数据点格式无效", legacy.Target)
+			}
+			add(legacy.Target, int64(*point[1]), point[0])
+		}
+	}
+	return series, nil
+}
+
+// Instances 快照中出现的实例，按名称排序
+func (snapshot *GrafanaSnapshot) Instances() []string {
+	seen := make(map[string]bool)
+	var instances []string
+	for _, series := range []grafanaSeries{snapshot.RaftstoreCPU, snapshot.CoprocessorCPU} {
+		for instance := range series {
+			if !seen[instance] {
+				seen[instance] = true
+				instances = append(instances, instance)
+			}
+		}
+	}
+	sort.Strings(instances)
+	return instances
+}
+
+// MonitorSeries 按时间戳把面板数据转换为 TiDBMonitor 时间序列，每个时间点的监控数据已计算统计信息。
+// 实例在某个时间点缺少数据时沿用该实例上一个时间点的值，还没有出现过数据的实例不加入节点列表；
+// CheckWriteHotspot / CheckReadHotspot 按面板是否存在设置
+func (snapshot *GrafanaSnapshot) MonitorSeries() []*MonitorSample {
+	timestamps := make(map[int64]bool)
+	for _, series := range []grafanaSeries{snapshot.RaftstoreCPU, snapshot.CoprocessorCPU} {
+		for _, points := range series {
+			for timestamp := range points {
+				timestamps[timestamp] = true
+			}
+		}
+	}
+	ordered := make([]int64, 0, len(timestamps))
+	for timestamp := range timestamps {
+		ordered = append(ordered, timestamp)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i] < ordered[j] })
+
+	instances := snapshot.Instances()
+	lastRaftstoreCPU := make(map[string]float64)
+	lastCoprocessorCPU := make(map[string]float64)
+	samples := make([]*MonitorSample, 0, len(ordered))
+	for _, timestamp := range ordered {
+		monitor := &TiDBMonitor{
+			CheckWriteHotspot: snapshot.RaftstoreCPU != nil,
+			CheckReadHotspot:  snapshot.CoprocessorCPU != nil,
+		}
+		for _, instance := range instances {
+			if value, ok := snapshot.RaftstoreCPU[instance][timestamp]; ok {
+				lastRaftstoreCPU[instance] = value
+			}
+			if value, ok := snapshot.CoprocessorCPU[instance][timestamp]; ok {
+				lastCoprocessorCPU[instance] = value
+			}
+			raftstoreCPU, hasRaftstore := lastRaftstoreCPU[instance]
+			coprocessorCPU, hasCoprocessor := lastCoprocessorCPU[instance]
+			if !hasRaftstore && !hasCoprocessor {
+				continue
+			}
+			node := &TiKVNode{NodeID: instance, RaftstoreCPU: raftstoreCPU, CoprocessorCPU: coprocessorCPU}
+			if strings.Contains(instance, ":") {
+				node.Address = instance
+			}
+			monitor.TiKVNodes = append(monitor.TiKVNodes, node)
+		}
+		monitor.CalculateStatistics()
+		samples = append(samples, &MonitorSample{Time: time.UnixMilli(timestamp), Monitor: monitor})
+	}
+	return samples
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// testGrafanaSnapshot 旧格式（Raft store CPU，折叠在 row 中）与 data frame 格式（Coprocessor CPU）混合的快照
+const testGrafanaSnapshot = `{
+  "dashboard": {
+    "title": "Test-Cluster-TiKV-Details",
+    "panels": [
+      {
+        "title": "Thread CPU",
+        "type": "row",
+        "panels": [
+          {
+            "title": "Raft store CPU",
+            "yaxes": [{"format": "percentunit"}, {"format": "short"}],
+            "snapshotData": [
+              {"target": "10.0.1.3:20160", "datapoints": [[0.30, 1716170400000], [0.32, 1716170430000]]},
+              {"target": "10.0.1.4:20160", "datapoints": [[0.28, 1716170400000], [0.30, 1716170430000]]},
+              {"target": "10.0.1.5:20160", "datapoints": [[0.31, 1716170400000], [0.95, 1716170430000]]}
+            ]
+          }
+        ]
+      },
+      {
+        "title": "Coprocessor CPU",
+        "fieldConfig": {"defaults": {"unit": "percentunit"}},
+        "snapshotData": [
+          {
+            "refId": "A",
+            "fields": [
+              {"name": "Time", "type": "time", "values": [1716170400000, 1716170430000, 1716170460000]},
+              {"name": "Value", "type": "number", "labels": {"instance": "10.0.1.3:20160"}, "values": [0.25, 0.20, 0.22]},
+              {"name": "Value", "type": "number", "labels": {"instance": "10.0.1.4:20160"}, "values": [0.22, null, 0.21]},
+              {"name": "Value", "type": "number", "labels": {"instance": "10.0.1.5:20160"}, "values": [0.90, 0.21, 0.24]}
+            ]
+          }
+        ]
+      },
+      {"title": "gRPC poll CPU", "snapshotData": [{"target": "10.0.1.3:20160", "datapoints": [[0.5, 1716170400000]]}]}
+    ]
+  }
+}`
+
+func TestParseGrafanaSnapshot(t *testing.T) {
+	snapshot, err := ParseGrafanaSnapshot(strings.NewReader(testGrafanaSnapshot))
+	if err != nil {
+		t.Fatalf("解析 Grafana 快照失败: %v", err)
+	}
+	if snapshot.Title != "Test-Cluster-TiKV-Details" || len(snapshot.Instances()) != 3 {
+		t.Fatalf("快照解析不符: %s %v", snapshot.Title, snapshot.Instances())
+	}
+	if value := snapshot.RaftstoreCPU["10.0.1.5:20160"][1716170430000]; value != 95 {
+		t.Errorf("percentunit 应换算为百分比，实际 %.2f", value)
+	}
+
+	samples := snapshot.MonitorSeries()
+	if len(samples) != 3 {
+		t.Fatalf("期望 3 个时间点，实际 %d 个", len(samples))
+	}
+	first := samples[0].Monitor
+	if samples[0].Time.UnixMilli() != 1716170400000 || len(first.TiKVNodes) != 3 ||
+		!first.CheckWriteHotspot || !first.CheckReadHotspot || first.ReadHotspotNode != "10.0.1.5:20160" {
+		t.Errorf("第一个时间点不符: %+v", first)
+	}
+	if second := samples[1].Monitor; second.WriteHotspotNode != "10.0.1.5:20160" || second.MaxRaftstoreCPU != 95 {
+		t.Errorf("第二个时间点写热点统计不符: node=%s max=%.2f", second.WriteHotspotNode, second.MaxRaftstoreCPU)
+	}
+	if second := samples[1].Monitor; second.TiKVNodes[1].CoprocessorCPU != 22 {
+		t.Errorf("缺少数据时应沿用上一个时间点的值，实际 %.2f", second.TiKVNodes[1].CoprocessorCPU)
+	}
+	if last := samples[2].Monitor; len(last.TiKVNodes) != 3 || last.MaxRaftstoreCPU != 95 || last.TiKVNodes[0].Address != "10.0.1.3:20160" {
+		t.Errorf("只有 Coprocessor 数据的时间点不符: %+v", last)
+	}
+
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	monitors := []*TiDBMonitor{samples[0].Monitor, samples[1].Monitor}
+	results := executor.EvaluateBatch(monitors, 2)
+	if len(results[0].Findings) != 1 || results[0].Findings[0].Type != FindingReadHotspot {
+		t.Errorf("第一个时间点应为读热点: %v", results[0].Findings)
+	}
+	if len(results[1].Findings) != 1 || results[1].Findings[0].Type != FindingWriteHotspot {
+		t.Errorf("第二个时间点应为写热点: %v", results[1].Findings)
+	}
+
+	if _, err := ParseGrafanaSnapshot(strings.NewReader(`{"panels": [{"title": "QPS"}]}`)); err == nil {
+		t.Errorf("没有 CPU 面板的快照应返回错误")
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultClusterID 未指定集群 ID 时记录使用的集群
+const DefaultClusterID = "default"
+
+// incidentBucket 顶层 bucket，其下每个集群一个子 bucket，key 为 时间（UnixNano）+ 序号，value 为 JSON 记录
+var incidentBucket = []byte("incidents")
+
+// IncidentRecord 一次规则评估的记录：输入快照、触发的规则和结论
+type IncidentRecord struct {
+	ID           uint64            `json:"id"` // 集群内的序号，由 IncidentStore.Record 分配
+	ClusterID    string            `json:"cluster_id"`
+	Time         time.Time         `json:"time"`
+	RuleName     string            `json:"rule_name"`
+	RuleVersion  string            `json:"rule_version"`
+	RuleSet      *RuleSet          `json:"rule_set,omitempty"`      // 生成记录的集群级规则集合，磁盘路径为绝对路径
+	NodeRuleSet  *RuleSet          `json:"node_rule_set,omitempty"` // 生成记录时执行的节点级规则集合，没有执行节点级规则时为空
+	FindingPacks map[string]string `json:"finding_packs,omitempty"` // 结论类型 -> 声明该结论类型的规则包（RulePackManifest.Findings），回放时用于确定对比范围
+	Input        ScenarioInput     `json:"input"`                   // 评估时的输入，可以通过 Input.BuildMonitor 重新评估
+	FiredRules   []string          `json:"fired_rules,omitempty"`
+	Findings     []Finding         `json:"findings,omitempty"`
+	Tables       []string          `json:"tables,omitempty"` // 涉及的表，见 incidentTables
+}
+
+// IncidentQuery 历史查询条件，空字段表示不限制
+type IncidentQuery struct {
+	ClusterID string
+	Node      string   // 结论对象为该节点（或该节点的线程池）
+	Table     string   // 记录涉及该表
+	Types     []string // 结论类型，如 write_hotspot
+	From      time.Time
+	To        time.Time // 包含 To 时刻的记录
+}
+
+// IncidentEpisode 同一集群中同一结论在连续多次评估中出现的一段时间
+type IncidentEpisode struct {
+	ClusterID   string    `json:"cluster_id"`
+	Type        string    `json:"type"`
+	Target      string    `json:"target,omitempty"`
+	Start       time.Time `json:"start"` // 第一次出现该结论的评估时间
+	End         time.Time `json:"end"`   // 最后一次出现该结论的评估时间
+	Evaluations int       `json:"evaluations"`
+	MaxValue    float64   `json:"max_value"`
+	Tables      []string  `json:"tables,omitempty"`
+	Ongoing     bool      `json:"ongoing"` // 查询范围内最后一次评估仍有该结论
+}
+
+// Key 结论标识，与 Finding.Key 相同
+func (episode *IncidentEpisode) Key() string {
+	return Finding{Type: episode.Type, Target: episode.Target}.Key()
+}
+
+// Duration 第一次到最后一次出现该结论的时间
+func (episode *IncidentEpisode) Duration() time.Duration {
+	return episode.End.Sub(episode.Start)
+}
+
+// IncidentStore 基于 bbolt 的本地事件历史，记录每次评估的输入快照和结论
+type IncidentStore struct {
+	db *bolt.DB
+}
+
+// OpenIncidentStore 打开（不存在时创建）事件历史文件，同一文件同时只能被一个进程打开
+func OpenIncidentStore(path string) (*IncidentStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("打开事件历史 %s 失败: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(incidentBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化事件历史 %s 失败: %v", path, err)
+	}
+	return &IncidentStore{db: db}, nil
+}
+
+// Close 关闭事件历史文件
+func (store *IncidentStore) Close() error {
+	return store.db.Close()
+}
+
+// NewIncidentRecord 根据评估后的监控数据生成记录，firedRules 为触发的规则（包括节点级规则），
+// nodeExecutor 为评估时使用的节点级规则执行器（没有时为 nil），记录中保存两者的规则集合供回放使用
+func (executor *TiDBRuleExecutor) NewIncidentRecord(clusterID string, at time.Time, monitor *TiDBMonitor, firedRules []string, nodeExecutor *TiKVNodeRuleExecutor) *IncidentRecord {
+	record := &IncidentRecord{
+		ClusterID:    clusterID,
+		Time:         at,
+		RuleName:     executor.RuleName(),
+		RuleVersion:  executor.RuleVersion(),
+		RuleSet:      recordedRuleSet(executor.RuleSet()),
+		FindingPacks: make(map[string]string),
+		Input:        monitor.Snapshot(),
+		FiredRules:   firedRules,
+		Findings:     monitor.Findings(),
+		Tables:       incidentTables(monitor),
+	}
+	manifests := executor.RulePacks()
+	if nodeExecutor != nil {
+		record.NodeRuleSet = recordedRuleSet(nodeExecutor.RuleSet())
+		manifests = append(append([]*RulePackManifest{}, manifests...), nodeExecutor.RulePacks()...)
+	}
+	for _, manifest := range manifests {
+		for _, findingType := range manifest.Findings {
+			record.FindingPacks[findingType] = manifest.Name
+		}
+	}
+	return record
+}
+
+// recordedRuleSet 记录中保存的规则集合：磁盘路径转换为绝对路径，在其他工作目录回放时仍然可以加载
+func recordedRuleSet(ruleSet RuleSet) *RuleSet {
+	absolute := func(path string) string {
+		if abs, err := filepath.Abs(path); err == nil && path != "" {
+			return abs
+		}
+		return path
+	}
+	recorded := &RuleSet{Packs: ruleSet.Packs, OverrideDir: absolute(ruleSet.OverrideDir)}
+	for _, dir := range ruleSet.PackDirs {
+		recorded.PackDirs = append(recorded.PackDirs, absolute(dir))
+	}
+	for _, file := range ruleSet.Files {
+		recorded.Files = append(recorded.Files, absolute(file))
+	}
+	return recorded
+}
+
+// incidentTables 记录涉及的表：写入最多的语句涉及的表（TopWriteTables）加上读热点节点上 Top SQL 涉及的表
+func incidentTables(monitor *TiDBMonitor) []string {
+	tables := splitList(monitor.TopWriteTables)
+	for _, digest := range monitor.ReadHotspotDigests {
+		for _, table := range digest.Tables {
+			if !containsString(tables, table) {
+				tables = append(tables, table)
+			}
+		}
+	}
+	return tables
+}
+
+// Snapshot 监控数据的输入部分（不包括统计信息和规则输出）
+func (monitor *TiDBMonitor) Snapshot() ScenarioInput {
+	return ScenarioInput{
+		CheckWriteHotspot:          monitor.CheckWriteHotspot,
+		CheckReadHotspot:           monitor.CheckReadHotspot,
+		IsNonClusteredIndexHotspot: monitor.IsNonClusteredIndexHotspot,
+		Nodes:                      monitor.TiKVNodes,
+		CheckTopology:              monitor.CheckTopology,
+		NormalizeCPU:               monitor.NormalizeCPU,
+		CheckTiDBServer:            monitor.CheckTiDBServer,
+		TiDBServers:                monitor.TiDBServers,
+		CheckTiKVThreadPools:       monitor.CheckTiKVThreadPools,
+		CheckWorkload:              monitor.CheckWorkload,
+		Statements:                 monitor.Statements,
+	}
+}
+
+// Record 在一个事务中写入多条记录，为每条记录分配 ID，ClusterID 为空时使用 DefaultClusterID
+func (store *IncidentStore) Record(records ...*IncidentRecord) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		for _, record := range records {
+			if record.ClusterID == "" {
+				record.ClusterID = DefaultClusterID
+			}
+			if record.Time.IsZero() {
+				return fmt.Errorf("集群 %s 的记录缺少时间", record.ClusterID)
+			}
+			cluster, err := tx.Bucket(incidentBucket).CreateBucketIfNotExists([]byte(record.ClusterID))
+			if err != nil {
+				return fmt.Errorf("创建集群 %s 的 bucket 失败: %v", record.ClusterID, err)
+			}
+			if record.ID, err = cluster.NextSequence(); err != nil {
+				return err
+			}
+			data, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("序列化记录失败: %v", err)
+			}
+			if err := cluster.Put(incidentKey(record.Time, record.ID), data); err != nil {
+				return fmt.Errorf("写入记录失败: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// incidentKey 记录的 key：时间在前保证按时间顺序遍历，序号区分同一时刻的多条记录
+func incidentKey(at time.Time, id uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(at.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], id)
+	return key
+}
+
+// Clusters 有记录的集群 ID，按名称排序
+func (store *IncidentStore) Clusters() ([]string, error) {
+	var clusters []string
+	err := store.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(incidentBucket).ForEachBucket(func(name []byte) error {
+			clusters = append(clusters, string(name))
+			return nil
+		})
+	})
+	return clusters, err
+}
+
+// scan 按时间顺序遍历集群在时间范围内的所有记录
+func (store *IncidentStore) scan(query IncidentQuery, visit func(record *IncidentRecord)) error {
+	clusters := []string{query.ClusterID}
+	if query.ClusterID == "" {
+		var err error
+		if clusters, err = store.Clusters(); err != nil {
+			return err
+		}
+	}
+
+	return store.db.View(func(tx *bolt.Tx) error {
+		for _, clusterID := range clusters {
+			cluster := tx.Bucket(incidentBucket).Bucket([]byte(clusterID))
+			if cluster == nil {
+				continue
+			}
+			cursor := cluster.Cursor()
+			key, value := cursor.First()
+			if !query.From.IsZero() {
+				key, value = cursor.Seek(incidentKey(query.From, 0))
+			}
+			for ; key != nil; key, value = cursor.Next() {
+				if !query.To.IsZero() && binary.BigEndian.Uint64(key) > uint64(query.To.UnixNano()) {
+					break
+				}
+				record := &IncidentRecord{}
+				if err := json.Unmarshal(value, record); err != nil {
+					return fmt.Errorf("解析集群 %s 的记录失败: %v", clusterID, err)
+				}
+				visit(record)
+			}
+		}
+		return nil
+	})
+}
+
+// Query 按时间顺序返回符合条件的评估记录（按集群分组）
+// 指定 Node / Types 时只返回有对应结论的记录，指定 Table 时只返回涉及该表的记录
+func (store *IncidentStore) Query(query IncidentQuery) ([]*IncidentRecord, error) {
+	var records []*IncidentRecord
+	err := store.scan(query, func(record *IncidentRecord) {
+		if query.Table != "" && !containsString(record.Tables, query.Table) {
+			return
+		}
+		if query.Node != "" || len(query.Types) > 0 {
+			matched := false
+			for _, finding := range record.Findings {
+				matched = matched || query.matchFinding(finding)
+			}
+			if !matched {
+				return
+			}
+		}
+		records = append(records, record)
+	})
+	return records, err
+}
+
+// Episodes 将时间范围内的记录按集群和结论合并为事件：同一结论在连续的评估中出现视为同一事件，
+// 某次评估没有该结论时事件结束。结果按开始时间排序
+func (store *IncidentStore) Episodes(query IncidentQuery) ([]*IncidentEpisode, error) {
+	var episodes []*IncidentEpisode
+	open := make(map[string]map[string]*IncidentEpisode) // 集群 -> 结论标识 -> 未结束的事件
+	err := store.scan(query, func(record *IncidentRecord) {
+		current := open[record.ClusterID]
+		if current == nil {
+			current = make(map[string]*IncidentEpisode)
+			open[record.ClusterID] = current
+		}
+		present := make(map[string]bool)
+		for _, finding := range record.Findings {
+			key := finding.Key()
+			present[key] = true
+			episode := current[key]
+			if episode == nil {
+				episode = &IncidentEpisode{ClusterID: record.ClusterID, Type: finding.Type, Target: finding.Target, Start: record.Time, MaxValue: finding.Value}
+				current[key] = episode
+				episodes = append(episodes, episode)
+			}
+			episode.End = record.Time
+			episode.Evaluations++
+			if finding.Value > episode.MaxValue {
+				episode.MaxValue = finding.Value
+			}
+			for _, table := range record.Tables {
+				if !containsString(episode.Tables, table) {
+					episode.Tables = append(episode.Tables, table)
+				}
+			}
+		}
+		for key := range current {
+			if !present[key] {
+				delete(current, key)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, current := range open {
+		for _, episode := range current {
+			episode.Ongoing = true
+		}
+	}
+
+	var matched []*IncidentEpisode
+	for _, episode := range episodes {
+		if query.Table != "" && !containsString(episode.Tables, query.Table) {
+			continue
+		}
+		if query.matchFinding(Finding{Type: episode.Type, Target: episode.Target}) {
+			matched = append(matched, episode)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Start.Before(matched[j].Start)
+	})
+	return matched, nil
+}
+
+// matchFinding 结论是否满足 Node / Types 条件，线程池结论的对象为 节点/线程池
+func (query IncidentQuery) matchFinding(finding Finding) bool {
+	if len(query.Types) > 0 && !containsString(query.Types, finding.Type) {
+		return false
+	}
+	return query.Node == "" || finding.Target == query.Node || strings.HasPrefix(finding.Target, query.Node+"/")
+}
+
+// containsString 切片中是否包含 value
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHistoryTime 解析命令行中的时间：RFC3339、"2006-01-02 15:04:05" 或 "2006-01-02"（本地时区），空字符串返回零值
+func ParseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间 %q，支持 RFC3339、\"2006-01-02 15:04:05\" 和 \"2006-01-02\"", value)
+}
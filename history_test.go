@@ -0,0 +1,195 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// historyMonitor 写热点出现在 hotNode（为空时没有热点）的监控数据
+func historyMonitor(hotNode string, tables string) *TiDBMonitor {
+	input := ScenarioInput{CheckWriteHotspot: true}
+	for _, id := range []string{"tikv-1", "tikv-2", "tikv-3"} {
+		cpu := 20.0
+		if id == hotNode {
+			cpu = 90
+		}
+		input.Nodes = append(input.Nodes, &TiKVNode{NodeID: id, RaftstoreCPU: cpu})
+	}
+	if tables != "" {
+		input.CheckWorkload = true
+		input.Statements = []*StatementSummary{{StmtType: "Insert", Digest: "d1", TableNames: tables, ExecCount: 100, AvgWriteKeys: 1}}
+	}
+	return input.BuildMonitor()
+}
+
+func TestIncidentStore(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "incidents.db")
+	store, err := OpenIncidentStore(path)
+	if err != nil {
+		t.Fatalf("打开事件历史失败: %v", err)
+	}
+
+	// prod: tikv-3 热点持续 2 次评估，恢复后 tikv-2 出现热点；staging: tikv-1 热点
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	evaluations := []struct {
+		cluster string
+		minute  int
+		hotNode string
+		tables  string
+	}{
+		{"prod", 0, "tikv-3", "orders"},
+		{"prod", 1, "tikv-3", "orders"},
+		{"prod", 2, "", ""},
+		{"prod", 3, "tikv-2", "users"},
+		{"staging", 1, "tikv-1", ""},
+	}
+	var records []*IncidentRecord
+	for _, evaluation := range evaluations {
+		monitor := historyMonitor(evaluation.hotNode, evaluation.tables)
+		firedRules, err := executor.ExecuteWithTrace(monitor)
+		if err != nil {
+			t.Fatalf("执行规则失败: %v", err)
+		}
+		records = append(records, executor.NewIncidentRecord(evaluation.cluster, start.Add(time.Duration(evaluation.minute)*time.Minute), monitor, firedRules, nil))
+	}
+	if err := store.Record(records...); err != nil {
+		t.Fatalf("写入事件历史失败: %v", err)
+	}
+	if err := store.Record(&IncidentRecord{ClusterID: "prod"}); err == nil {
+		t.Errorf("缺少时间的记录应返回错误")
+	}
+	store.Close()
+
+	// 重新打开后数据仍在
+	store, err = OpenIncidentStore(path)
+	if err != nil {
+		t.Fatalf("重新打开事件历史失败: %v", err)
+	}
+	defer store.Close()
+	if clusters, _ := store.Clusters(); strings.Join(clusters, ",") != "prod,staging" {
+		t.Errorf("集群列表不符: %v", clusters)
+	}
+
+	episodes, err := store.Episodes(IncidentQuery{ClusterID: "prod", Types: []string{FindingWriteHotspot}})
+	if err != nil {
+		t.Fatalf("查询事件失败: %v", err)
+	}
+	if len(episodes) != 2 {
+		t.Fatalf("事件数不符: %+v", episodes)
+	}
+	first, second := episodes[0], episodes[1]
+	if first.Key() != "write_hotspot@tikv-3" || first.Evaluations != 2 || first.Duration() != time.Minute || first.Ongoing ||
+		strings.Join(first.Tables, ",") != "orders" || first.MaxValue < 2 {
+		t.Errorf("第一个事件不符: %+v", first)
+	}
+	if second.Key() != "write_hotspot@tikv-2" || !second.Ongoing || !second.Start.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("第二个事件不符: %+v", second)
+	}
+
+	// 按节点、表和时间范围过滤
+	for name, test := range map[string]struct {
+		query IncidentQuery
+		want  string
+	}{
+		"节点":   {IncidentQuery{Node: "tikv-1", Types: []string{FindingWriteHotspot}}, "staging/write_hotspot@tikv-1"},
+		"表":    {IncidentQuery{Table: "users", Types: []string{FindingWriteHotspot}}, "prod/write_hotspot@tikv-2"},
+		"时间范围": {IncidentQuery{ClusterID: "prod", Types: []string{FindingWriteHotspot}, From: start.Add(time.Minute), To: start.Add(2 * time.Minute)}, "prod/write_hotspot@tikv-3"},
+		"所有集群": {IncidentQuery{Types: []string{FindingWriteHotspot}, From: start.Add(time.Minute), To: start.Add(time.Minute)}, "prod/write_hotspot@tikv-3,staging/write_hotspot@tikv-1"},
+	} {
+		episodes, err := store.Episodes(test.query)
+		if err != nil {
+			t.Fatalf("%s: 查询事件失败: %v", name, err)
+		}
+		var keys []string
+		for _, episode := range episodes {
+			keys = append(keys, episode.ClusterID+"/"+episode.Key())
+		}
+		if got := strings.Join(keys, ","); got != test.want {
+			t.Errorf("%s: 事件不符: %s", name, got)
+		}
+	}
+
+	// 评估记录保留输入快照，可以重新构造监控数据
+	found, err := store.Query(IncidentQuery{ClusterID: "prod", Node: "tikv-3"})
+	if err != nil {
+		t.Fatalf("查询记录失败: %v", err)
+	}
+	if len(found) != 2 || found[0].ID != 1 || found[0].RuleVersion != "1.0.0" || !containsString(found[0].FiredRules, "DetectWriteHotspot") {
+		t.Fatalf("记录不符: %+v", found)
+	}
+	rebuilt := found[1].Input.BuildMonitor()
+	if rebuilt.WriteHotspotNode != "tikv-3" || rebuilt.TopWriteTables != "orders" {
+		t.Errorf("输入快照重建后的统计信息不符: %s %q", rebuilt.WriteHotspotNode, rebuilt.TopWriteTables)
+	}
+	if all, _ := store.Query(IncidentQuery{ClusterID: "prod"}); len(all) != 4 {
+		t.Errorf("prod 应有 4 条记录: %d", len(all))
+	}
+}
+
+func TestIncidentRecordReadHotspotTables(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	queries, err := ParseSlowLog(strings.NewReader(testSlowLog))
+	if err != nil {
+		t.Fatalf("解析慢日志失败: %v", err)
+	}
+	monitor := &TiDBMonitor{
+		CheckReadHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", Address: "10.0.1.3:20160", CoprocessorCPU: 25},
+			{NodeID: "tikv-2", Address: "10.0.1.4:20160", CoprocessorCPU: 28},
+			{NodeID: "tikv-3", Address: "10.0.1.5:20160", CoprocessorCPU: 90},
+		},
+	}
+	monitor.AttachSlowQueries(queries)
+	monitor.CalculateStatistics()
+	firedRules, err := executor.ExecuteWithTrace(monitor)
+	if err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+
+	// 读热点没有写入语句，涉及的表来自读热点节点上的 Top SQL（digest-users-lookup 没有 DB）
+	record := executor.NewIncidentRecord("prod", time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC), monitor, firedRules, nil)
+	if strings.Join(record.Tables, ",") != "shop.orders,users" {
+		t.Fatalf("记录涉及的表不符: %v", record.Tables)
+	}
+
+	store, err := OpenIncidentStore(filepath.Join(t.TempDir(), "incidents.db"))
+	if err != nil {
+		t.Fatalf("打开事件历史失败: %v", err)
+	}
+	defer store.Close()
+	if err := store.Record(record); err != nil {
+		t.Fatalf("写入事件历史失败: %v", err)
+	}
+	episodes, err := store.Episodes(IncidentQuery{Table: "shop.orders"})
+	if err != nil || len(episodes) != 1 || episodes[0].Key() != "read_hotspot@tikv-3" {
+		t.Errorf("按表查询读热点事件不符: %+v, %v", episodes, err)
+	}
+
+	if tables := queryTables("shop", "SELECT * FROM `app`.`orders` o JOIN users u ON o.uid = u.id"); strings.Join(tables, ",") != "app.orders,shop.users" {
+		t.Errorf("SQL 中的表解析不符: %v", tables)
+	}
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	for _, value := range []string{"2024-05-01T10:00:00+08:00", "2024-05-01 10:00:00", "2024-05-01"} {
+		if parsed, err := ParseHistoryTime(value); err != nil || parsed.IsZero() {
+			t.Errorf("%s: 解析失败: %v", value, err)
+		}
+	}
+	if parsed, err := ParseHistoryTime(""); err != nil || !parsed.IsZero() {
+		t.Errorf("空字符串应返回零值")
+	}
+	if _, err := ParseHistoryTime("yesterday"); err == nil {
+		t.Errorf("无法解析的时间应返回错误")
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+)
+
+// 支持的语言
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEnUS = "en-US"
+
+	// DefaultLocale 未指定语言时使用的语言，翻译缺失时也回退到该语言
+	DefaultLocale = LocaleZhCN
+
+	// localeEnv 命令行默认语言的环境变量
+	localeEnv = "GRULE_DIAG_LANG"
+)
+
+// localeFS 编译进二进制的消息目录，每种语言一个 JSON 文件（消息键 -> fmt 格式串）
+//
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// messageCatalog 语言 -> 消息键 -> 格式串
+var messageCatalog = mustLoadCatalog()
+
+// currentLocale 进程级默认语言（命令行 -lang 参数或 GRULE_DIAG_LANG 环境变量），TiDBMonitor.Locale 为空时使用
+var (
+	currentLocale     = DefaultLocale
+	currentLocaleLock sync.RWMutex
+)
+
+// mustLoadCatalog 加载内置消息目录，内置文件格式错误属于编译期问题，直接 panic
+func mustLoadCatalog() map[string]map[string]string {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("读取内置消息目录失败: %v", err))
+	}
+	catalog := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("读取内置消息文件 %s 失败: %v", entry.Name(), err))
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("解析内置消息文件 %s 失败: %v", entry.Name(), err))
+		}
+		catalog[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return catalog
+}
+
+// Locales 支持的语言，按名称排序
+func Locales() []string {
+	locales := make([]string, 0, len(messageCatalog))
+	for locale := range messageCatalog {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// NormalizeLocale 规范化语言名称，接受 zh、zh_CN、en、en-us、en_US.UTF-8 等写法
+func NormalizeLocale(locale string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(locale))
+	if index := strings.IndexAny(normalized, ".@"); index >= 0 {
+		normalized = normalized[:index]
+	}
+	normalized = strings.ReplaceAll(normalized, "_", "-")
+	for _, supported := range Locales() {
+		lower := strings.ToLower(supported)
+		if normalized == lower || normalized == strings.SplitN(lower, "-", 2)[0] {
+			return supported, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的语言: %q（支持: %s）", locale, strings.Join(Locales(), ", "))
+}
+
+// SetLocale 设置进程级默认语言
+func SetLocale(locale string) error {
+	normalized, err := NormalizeLocale(locale)
+	if err != nil {
+		return err
+	}
+	currentLocaleLock.Lock()
+	defer currentLocaleLock.Unlock()
+	currentLocale = normalized
+	return nil
+}
+
+// CurrentLocale 进程级默认语言
+func CurrentLocale() string {
+	currentLocaleLock.RLock()
+	defer currentLocaleLock.RUnlock()
+	return currentLocale
+}
+
+// localeFromEnv 从 GRULE_DIAG_LANG 环境变量设置默认语言，未设置时不变
+func localeFromEnv() error {
+	if locale := os.Getenv(localeEnv); locale != "" {
+		return SetLocale(locale)
+	}
+	return nil
+}
+
+// parseLocaleArgs 按 GRULE_DIAG_LANG 环境变量和开头的 -lang / --lang 参数设置默认语言，返回剩余参数
+func parseLocaleArgs(args []string) ([]string, error) {
+	if err := localeFromEnv(); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return args, nil
+	}
+	name := strings.TrimLeft(args[0], "-")
+	if !strings.HasPrefix(args[0], "-") || (name != "lang" && !strings.HasPrefix(name, "lang=")) {
+		return args, nil
+	}
+	if value := strings.TrimPrefix(name, "lang="); value != name {
+		return args[1:], SetLocale(value)
+	}
+	if len(args) < 2 {
+		return nil, fmt.Errorf("-lang 参数缺少语言")
+	}
+	return args[2:], SetLocale(args[1])
+}
+
+// lookupMessage 按 locale -> DefaultLocale 的顺序查找消息
+func lookupMessage(locale, key string) (string, bool) {
+	if locale == "" {
+		locale = CurrentLocale()
+	}
+	if format, ok := messageCatalog[locale][key]; ok {
+		return format, true
+	}
+	format, ok := messageCatalog[DefaultLocale][key]
+	return format, ok
+}
+
+// Translate 将消息键翻译为指定语言的文本，locale 为空时使用进程级默认语言，消息不存在时返回键本身
+func Translate(locale, key string, args ...interface{}) string {
+	format, ok := lookupMessage(locale, key)
+	if !ok {
+		if len(args) == 0 {
+			return key
+		}
+		return fmt.Sprintf("%s %v", key, args)
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// T 使用进程级默认语言翻译消息
+func T(key string, args ...interface{}) string {
+	return Translate("", key, args...)
+}
+
+// RuleMessage 规则通过 TiDBMonitor.Message 输出的消息
+type RuleMessage struct {
+	Key  string        `json:"key"`
+	Args []interface{} `json:"args,omitempty"`
+	Text string        `json:"text"` // 按 TiDBMonitor.Locale 翻译后的文本
+}
+
+// Message 规则中输出本地化消息，代替 Log("...")：
+//
+//	TiDBMonitor.Message("tidb.write_hotspot.detected", TiDBMonitor.WriteHotspotNode);
+//
+// 消息按 TiDBMonitor.Locale 翻译后写入规则日志，并记录在 Messages 中
+func (monitor *TiDBMonitor) Message(key string, args ...interface{}) {
+	text := Translate(monitor.Locale, key, args...)
+	monitor.Messages = append(monitor.Messages, RuleMessage{Key: key, Args: args, Text: text})
+	ast.GrlLogger.Println(text)
+}
+
+// LocalizedDescription 规则包描述：内置规则包使用消息目录中的 pack.<包名>，磁盘规则包和缺少翻译时使用清单中的描述
+func (manifest *RulePackManifest) LocalizedDescription(locale string) string {
+	if manifest.embedded {
+		if format, ok := lookupMessage(locale, "pack."+manifest.Name); ok {
+			return format
+		}
+	}
+	return manifest.Description
+}
+
+// LocalizedRuleDescription 规则描述：消息目录中存在 rule.<规则名> 时使用翻译，否则使用 GRL 中的描述
+// zh-CN 消息目录有意不包含 rule.* 键，中文描述直接取自 GRL，避免同一段文本维护两份；
+// 因此 en-US 缺少某条规则的翻译时回退到 zh-CN 也会落到 GRL 描述上
+func (executor *RuleExecutor) LocalizedRuleDescription(locale, ruleName string) string {
+	if format, ok := lookupMessage(locale, "rule."+ruleName); ok {
+		return format
+	}
+	return executor.RuleDescription(ruleName)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMessageCatalog(t *testing.T) {
+	// 除 rule.* 外两种语言的消息键一致（中文规则描述直接使用 GRL 中的描述）
+	for _, pair := range [][2]string{{LocaleZhCN, LocaleEnUS}, {LocaleEnUS, LocaleZhCN}} {
+		for key := range messageCatalog[pair[0]] {
+			if strings.HasPrefix(key, "rule.") {
+				continue
+			}
+			if _, ok := messageCatalog[pair[1]][key]; !ok {
+				t.Errorf("%s 中缺少消息 %s", pair[1], key)
+			}
+		}
+	}
+
+	// 内置 TiDB 规则包（包括决策表生成的规则）中的消息键和规则都有英文翻译
+	messageKey := regexp.MustCompile(`(?:TiDBMonitor|NodeResult)\.Message\("([^"]+)"`)
+	ruleName := regexp.MustCompile(`(?m)^rule\s+(\w+)`)
+	grlFiles, err := fs.Glob(builtinRuleFS, "t*.grl")
+	if err != nil || len(grlFiles) == 0 {
+		t.Fatalf("查找内置规则文件失败: %v", err)
+	}
+	tables, err := fs.Glob(builtinRuleFS, "t*.table.yaml")
+	if err != nil {
+		t.Fatalf("查找内置决策表失败: %v", err)
+	}
+	for _, file := range append(grlFiles, tables...) {
+		data, err := fs.ReadFile(builtinRuleFS, file)
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", file, err)
+		}
+		if isDecisionTableFile(file) {
+			table, err := LoadDecisionTable(builtinRuleFS, file)
+			if err != nil {
+				t.Fatalf("加载决策表失败: %v", err)
+			}
+			grl, err := table.Compile()
+			if err != nil {
+				t.Fatalf("编译决策表失败: %v", err)
+			}
+			data = []byte(grl)
+		}
+		for _, match := range messageKey.FindAllStringSubmatch(string(data), -1) {
+			if _, ok := messageCatalog[LocaleZhCN][match[1]]; !ok {
+				t.Errorf("%s: 消息 %s 不在消息目录中", file, match[1])
+			}
+		}
+		if strings.Contains(string(data), "Log(") {
+			t.Errorf("%s: 应使用 TiDBMonitor.Message / NodeResult.Message 代替 Log", file)
+		}
+		for _, match := range ruleName.FindAllStringSubmatch(string(data), -1) {
+			if _, ok := messageCatalog[LocaleEnUS]["rule."+match[1]]; !ok {
+				t.Errorf("%s: 规则 %s 缺少英文描述", file, match[1])
+			}
+		}
+	}
+}
+
+func TestCommandMessages(t *testing.T) {
+	// 示例和子命令通过 T("...") 输出的消息键都在两种语言的消息目录中
+	sourceKey := regexp.MustCompile(`\bT\("([^"]+)"`)
+	sources, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, file := range sources {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range sourceKey.FindAllStringSubmatch(string(data), -1) {
+			found++
+			for _, locale := range []string{LocaleZhCN, LocaleEnUS} {
+				if _, ok := messageCatalog[locale][match[1]]; !ok {
+					t.Errorf("%s: 消息 %s 不在 %s 消息目录中", file, match[1], locale)
+				}
+			}
+		}
+	}
+	if found == 0 {
+		t.Fatalf("没有找到 T(...) 调用")
+	}
+
+	if got := Translate(LocaleEnUS, "cmd.test.summary", 3, 2, 1); got != "\n3 scenarios, 2 passed, 1 failed\n" {
+		t.Errorf("子命令消息的英文翻译不符: %q", got)
+	}
+	if got := Translate(LocaleZhCN, "cmd.test.summary", 3, 2, 1); got != "\n共 3 个场景，通过 2 个，失败 1 个\n" {
+		t.Errorf("子命令消息的中文翻译不符: %q", got)
+	}
+
+	// 内置规则包的描述都有翻译，中文翻译与清单一致；磁盘规则包使用清单中的描述
+	for _, manifest := range NewBuiltinRulePackRegistry().Packs() {
+		if got := manifest.LocalizedDescription(LocaleZhCN); got != manifest.Description {
+			t.Errorf("规则包 %s 的中文描述与清单不一致: %s", manifest.Name, got)
+		}
+		if got := manifest.LocalizedDescription(LocaleEnUS); got == manifest.Description || strings.HasPrefix(got, "pack.") {
+			t.Errorf("规则包 %s 缺少英文描述: %s", manifest.Name, got)
+		}
+	}
+	disk := &RulePackManifest{Name: "tidb-hotspot", Description: "自定义热点规则"}
+	if got := disk.LocalizedDescription(LocaleEnUS); got != disk.Description {
+		t.Errorf("磁盘规则包应使用清单中的描述: %s", got)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if got := Translate(LocaleEnUS, "msg.write_hotspot.detected", "tikv-3"); got != "Write hotspot detected! Node: tikv-3" {
+		t.Errorf("英文翻译不符: %s", got)
+	}
+	if got := Translate(LocaleZhCN, "msg.write_hotspot.detected", "tikv-3"); got != "检测到写热点！节点: tikv-3" {
+		t.Errorf("中文翻译不符: %s", got)
+	}
+	if got := Translate(LocaleEnUS, "no.such.key", 1); got != "no.such.key [1]" {
+		t.Errorf("缺失的消息应返回键本身: %s", got)
+	}
+	if got := Translate("fr-FR", "finding.write_hotspot"); got != "写热点" {
+		t.Errorf("未知语言应回退到默认语言: %s", got)
+	}
+
+	for input, want := range map[string]string{"en": LocaleEnUS, "en_US.UTF-8": LocaleEnUS, "ZH-cn": LocaleZhCN, "zh": LocaleZhCN} {
+		if got, err := NormalizeLocale(input); err != nil || got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, %v", input, got, err)
+		}
+	}
+	if _, err := NormalizeLocale("fr"); err == nil {
+		t.Errorf("不支持的语言应返回错误")
+	}
+	if got := strings.Join(Locales(), ","); got != "en-US,zh-CN" {
+		t.Errorf("支持的语言不符: %s", got)
+	}
+
+	defer SetLocale(CurrentLocale())
+	t.Setenv(localeEnv, "")
+	args, err := parseLocaleArgs([]string{"-lang", "en", "report", "x.yaml"})
+	if err != nil || strings.Join(args, " ") != "report x.yaml" || CurrentLocale() != LocaleEnUS {
+		t.Errorf("-lang 参数解析不符: %v %v %s", args, err, CurrentLocale())
+	}
+	args, err = parseLocaleArgs([]string{"--lang=zh", "test"})
+	if err != nil || strings.Join(args, " ") != "test" || CurrentLocale() != LocaleZhCN {
+		t.Errorf("--lang= 参数解析不符: %v %v %s", args, err, CurrentLocale())
+	}
+	if _, err := parseLocaleArgs([]string{"-lang"}); err == nil {
+		t.Errorf("-lang 缺少语言应返回错误")
+	}
+}
+
+func TestLocalizedRuleOutput(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	monitor := &TiDBMonitor{
+		Locale:                     LocaleEnUS,
+		CheckWriteHotspot:          true,
+		CheckReadHotspot:           true,
+		IsNonClusteredIndexHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 25.3, CoprocessorCPU: 22.1},
+			{NodeID: "tikv-2", RaftstoreCPU: 28.7, CoprocessorCPU: 24.5},
+			{NodeID: "tikv-3", RaftstoreCPU: 95.8, CoprocessorCPU: 23.2},
+			{NodeID: "tikv-4", RaftstoreCPU: 26.2, CoprocessorCPU: 25.1},
+		},
+	}
+	monitor.CalculateStatistics()
+
+	report, err := executor.Diagnose(monitor)
+	if err != nil {
+		t.Fatalf("生成报告失败: %v", err)
+	}
+
+	var keys []string
+	for _, message := range monitor.Messages {
+		keys = append(keys, message.Key)
+		if message.Key == "msg.shard_row_id_bits.recommend" && !strings.Contains(message.Text, "SHARD_ROW_ID_BITS=10 to scatter") {
+			t.Errorf("规则消息未按 en-US 翻译: %s", message.Text)
+		}
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "msg.read_hotspot.none,msg.shard_row_id_bits.recommend,msg.write_hotspot.detected" {
+		t.Errorf("规则消息不符: %v", keys)
+	}
+	if cloned := monitor.Clone(); len(cloned.Messages) != 3 || &cloned.Messages[0] == &monitor.Messages[0] {
+		t.Errorf("Clone 应复制规则消息")
+	}
+
+	if len(report.Findings) != 2 || report.Findings[0].Title != "Write hotspot" ||
+		report.Findings[1].Recommendation != "Set SHARD_ROW_ID_BITS=10 to scatter the implicit RowID" {
+		t.Errorf("报告结论未按 en-US 翻译: %+v", report.Findings)
+	}
+	for _, rule := range report.Rules {
+		if rule.Name == "DetectWriteHotspot" && !strings.HasPrefix(rule.Description, "Detect TiDB write hotspots") {
+			t.Errorf("规则描述未按 en-US 翻译: %s", rule.Description)
+		}
+	}
+
+	var markdown bytes.Buffer
+	if err := report.Write(&markdown, ReportFormatMarkdown); err != nil {
+		t.Fatalf("渲染 Markdown 失败: %v", err)
+	}
+	for _, want := range []string{"# TiDB Cluster Diagnostic Report", "## Findings and Recommendations", "### write_hotspot@tikv-3: Write hotspot", "🔥 write hotspot"} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Errorf("英文 Markdown 报告缺少 %q:\n%s", want, markdown.String())
+		}
+	}
+	if regexp.MustCompile(`\p{Han}`).MatchString(markdown.String()) {
+		t.Errorf("英文报告中不应包含中文:\n%s", markdown.String())
+	}
+
+	var html bytes.Buffer
+	if err := report.Write(&html, ReportFormatHTML); err != nil {
+		t.Fatalf("渲染 HTML 失败: %v", err)
+	}
+	if !strings.Contains(html.String(), `<html lang="en-US">`) {
+		t.Errorf("HTML 报告语言不符")
+	}
+}
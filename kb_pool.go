@@ -0,0 +1,47 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+)
+
+// KnowledgeBasePool 知识库实例池，实例归还时重置撤回状态和工作内存，取出即可直接执行
+// 高频评估时复用已克隆的实例，避免每次执行都从 KnowledgeLibrary 克隆
+type KnowledgeBasePool struct {
+	pool    sync.Pool
+	created int64
+}
+
+// newKnowledgeBasePool 创建知识库实例池，newInstance 在池中没有空闲实例时创建新实例
+func newKnowledgeBasePool(newInstance func() *ast.KnowledgeBase) *KnowledgeBasePool {
+	pool := &KnowledgeBasePool{}
+	pool.pool.New = func() interface{} {
+		atomic.AddInt64(&pool.created, 1)
+		return newInstance()
+	}
+	return pool
+}
+
+// Get 取出一个已重置的知识库实例，用完后需要调用 Put 归还
+func (pool *KnowledgeBasePool) Get() *ast.KnowledgeBase {
+	return pool.pool.Get().(*ast.KnowledgeBase)
+}
+
+// Put 重置实例的撤回状态和工作内存后放回池中
+func (pool *KnowledgeBasePool) Put(knowledgeBase *ast.KnowledgeBase) {
+	resetKnowledgeBase(knowledgeBase)
+	pool.pool.Put(knowledgeBase)
+}
+
+// Created 池累计创建（克隆）的实例数量
+func (pool *KnowledgeBasePool) Created() int64 {
+	return atomic.LoadInt64(&pool.created)
+}
+
+// resetKnowledgeBase 恢复被 Retract 撤回的规则并清空工作内存中的求值结果
+func resetKnowledgeBase(knowledgeBase *ast.KnowledgeBase) {
+	knowledgeBase.Reset()
+	knowledgeBase.WorkingMemory.ResetAll()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+	"github.com/sirupsen/logrus"
+)
+
+// benchmarkRuleSetSizes 基准测试使用的额外规则数量
+var benchmarkRuleSetSizes = []int{0, 10, 100, 500}
+
+// writeSyntheticRules 生成 count 条与热点规则同构的额外规则，用于衡量规则集合规模对加载和执行的影响
+func writeSyntheticRules(tb testing.TB, count int) []string {
+	tb.Helper()
+	if count == 0 {
+		return nil
+	}
+	var builder strings.Builder
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&builder, `rule Synthetic%d "合成规则 %d" salience 1 {
+    when
+        TiDBMonitor.CheckWriteHotspot == true && TiDBMonitor.MaxRaftstoreCPU > %d && TiDBMonitor.MaxRaftstoreCPU > TiDBMonitor.AvgRaftstoreCPU * 1.2
+    then
+        Retract("Synthetic%d");
+}
+
+`, i, i, i%100, i)
+	}
+	path := filepath.Join(tb.TempDir(), "synthetic.grl")
+	if err := os.WriteFile(path, []byte(builder.String()), 0644); err != nil {
+		tb.Fatal(err)
+	}
+	return []string{path}
+}
+
+func newBenchmarkMonitor() *TiDBMonitor {
+	monitor := &TiDBMonitor{
+		CheckWriteHotspot:          true,
+		CheckReadHotspot:           true,
+		IsNonClusteredIndexHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 30.5, CoprocessorCPU: 25.3},
+			{NodeID: "tikv-2", RaftstoreCPU: 32.1, CoprocessorCPU: 28.7},
+			{NodeID: "tikv-3", RaftstoreCPU: 85.2, CoprocessorCPU: 22.1},
+			{NodeID: "tikv-4", RaftstoreCPU: 29.8, CoprocessorCPU: 26.5},
+			{NodeID: "tikv-5", RaftstoreCPU: 31.2, CoprocessorCPU: 90.4},
+		},
+	}
+	monitor.CalculateStatistics()
+	return monitor
+}
+
+// quietRuleLogs 基准测试期间关闭规则中的 Log 输出
+func quietRuleLogs(b *testing.B) {
+	astLog, grlLogger := ast.AstLog, ast.GrlLogger
+	quiet := logrus.New()
+	quiet.SetOutput(io.Discard)
+	ast.SetLogger(quiet)
+	b.Cleanup(func() {
+		ast.AstLog, ast.GrlLogger = astLog, grlLogger
+	})
+}
+
+func TestKnowledgeBasePool(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	pool := executor.InstancePool()
+
+	knowledgeBase := pool.Get()
+	if knowledgeBase == executor.knowledgeBase {
+		t.Fatalf("实例池不能返回执行器的共享实例")
+	}
+	if result := executor.executeBatchItem(knowledgeBase, 0, executor.Facts(newBenchmarkMonitor())); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	retracted := 0
+	for _, entry := range knowledgeBase.RuleEntries {
+		if entry.Retracted {
+			retracted++
+		}
+	}
+	if retracted == 0 {
+		t.Fatalf("执行后应有规则被撤回")
+	}
+
+	pool.Put(knowledgeBase)
+	for _, entry := range knowledgeBase.RuleEntries {
+		if entry.Retracted {
+			t.Errorf("归还后规则 %s 仍处于撤回状态", entry.RuleName)
+		}
+	}
+	if pool.Created() < 1 {
+		t.Errorf("实例池应至少创建一个实例")
+	}
+}
+
+func BenchmarkLoadRuleSet(b *testing.B) {
+	quietRuleLogs(b)
+	for _, size := range benchmarkRuleSetSizes {
+		b.Run(fmt.Sprintf("extra=%d", size), func(b *testing.B) {
+			files := writeSyntheticRules(b, size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, files, "TiDBHotspot", "1.0.0"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkExecute(b *testing.B) {
+	quietRuleLogs(b)
+	for _, size := range benchmarkRuleSetSizes {
+		b.Run(fmt.Sprintf("extra=%d", size), func(b *testing.B) {
+			executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, writeSyntheticRules(b, size), "TiDBHotspot", "1.0.0")
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				monitor := newBenchmarkMonitor()
+				b.StartTimer()
+				if err := executor.Execute(monitor); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkKnowledgeBaseInstance(b *testing.B) {
+	quietRuleLogs(b)
+	for _, size := range benchmarkRuleSetSizes {
+		executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, writeSyntheticRules(b, size), "TiDBHotspot", "1.0.0")
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("clone/extra=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				executor.newKnowledgeBaseInstance()
+			}
+		})
+		b.Run(fmt.Sprintf("pool/extra=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				executor.InstancePool().Put(executor.InstancePool().Get())
+			}
+		})
+	}
+}
+
+func BenchmarkEvaluateBatch(b *testing.B) {
+	quietRuleLogs(b)
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, writeSyntheticRules(b, 100), "TiDBHotspot", "1.0.0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				monitors := make([]*TiDBMonitor, 50)
+				for j := range monitors {
+					monitors[j] = newBenchmarkMonitor()
+				}
+				b.StartTimer()
+				for _, result := range executor.EvaluateBatch(monitors, workers) {
+					if result.Err != nil {
+						b.Fatal(result.Err)
+					}
+				}
+			}
+		})
+	}
+}
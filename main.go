@@ -3,30 +3,78 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 )
 
 func main() {
+	// 输出语言: GRULE_DIAG_LANG 环境变量，或子命令之前的 -lang 参数，例如: grule-diag -lang en-US report ...
+	args, err := parseLocaleArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// grule-diag 子命令，例如: grule-diag test scenarios/
+	if len(args) > 0 {
+		os.Exit(runCommand(args[0], args[1:]))
+	}
+
 	// 可以选择运行不同的示例
 	// carRuleExecutor()
 	tidbRuleExecutor()
 }
 
+func carRuleExecutor() {
+	fmt.Println(T("main.car.title"))
+
+	// 1. 初始化车辆模拟器（内置 car 规则包）
+	simulator, err := NewCarSimulator(nil)
+	if err != nil {
+		log.Fatal(T("main.car.init_failed", err))
+	}
+	fmt.Println(T("main.pack_loaded"))
+
+	// 2. 加速 12 个 tick（到达最大速度后保持），滑行 3 个 tick，再刹车 5 个 tick
+	plan := CarDrivingPlan{
+		{Action: CarAccelerate, Ticks: 12},
+		{Action: CarCoast, Ticks: 3},
+		{Action: CarBrake, Ticks: 5},
+	}
+	car := &TestCar{MaxSpeed: 100, SpeedIncrement: 10}
+	record := &DistanceRecord{}
+
+	// 3. 逐 tick 执行规则，TestCar 和 DistanceRecord 的状态跨 tick 保留
+	timeline, err := simulator.Run(car, record, plan, plan.Ticks())
+	if err != nil {
+		log.Fatal(T("main.execute_failed", err))
+	}
+
+	// 4. 输出时间线
+	fmt.Println()
+	if err := WriteCarTimelineCSV(os.Stdout, timeline); err != nil {
+		log.Fatal(T("main.car.timeline_failed", err))
+	}
+	fmt.Print(T("main.car.result", car.Speed, record.TotalDistance))
+}
+
 func tidbRuleExecutor() {
-	fmt.Println("=== TiDB 热点检测规则引擎示例 ===")
+	fmt.Println(T("main.tidb.title"))
 
-	// 1. 初始化规则执行器（单个规则文件）
-	ruleFile := "tidb.grl"
-	ruleExecutor, err := NewTiDBRuleExecutor(ruleFile, "TiDBHotspot", "1.0.0")
+	// 1. 初始化规则执行器（内置规则包，不依赖工作目录）
+	ruleExecutor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
 	if err != nil {
-		log.Fatalf("初始化规则执行器失败: %v", err)
+		log.Fatal(T("main.tidb.init_failed", err))
 	}
-	fmt.Println("✓ 规则文件加载成功")
+	fmt.Println(T("main.pack_loaded"))
+
+	// 示例：使用磁盘上的规则文件（SHARD_ROW_ID_BITS 建议在决策表中，需要一起列出）
+	// ruleExecutor, err := NewTiDBRuleExecutorWithFiles([]string{"tidb.grl", "tidb_shard_row_id_bits.table.yaml"}, "TiDBHotspot", "1.0.0")
 
 	// 示例：使用多个规则文件
 	// ruleFiles := []string{"tidb.grl", "tidb-advanced.grl"}
 	// ruleExecutor, err := NewTiDBRuleExecutorWithFiles(ruleFiles, "TiDBHotspot", "1.0.0")
 	// if err != nil {
-	// 	log.Fatalf("初始化规则执行器失败: %v", err)
+	// 	log.Fatal(T("main.tidb.init_failed", err))
 	// }
 	// fmt.Printf("✓ 成功加载 %d 个规则文件\n", len(ruleFiles))
 
@@ -48,55 +96,55 @@ func tidbRuleExecutor() {
 	// 3. 计算统计信息
 	monitor.CalculateStatistics()
 
-	fmt.Printf("\n初始监控数据:\n")
-	fmt.Printf("  TiKV 节点数量: %d\n", len(monitor.TiKVNodes))
+	fmt.Print(T("main.tidb.initial_data"))
+	fmt.Print(T("main.tidb.node_count", len(monitor.TiKVNodes)))
 	for _, node := range monitor.TiKVNodes {
-		fmt.Printf("  节点 %s: Raftstore CPU=%.2f%%, Coprocessor CPU=%.2f%%\n",
-			node.NodeID, node.RaftstoreCPU, node.CoprocessorCPU)
+		fmt.Print(T("main.tidb.node",
+			node.NodeID, node.RaftstoreCPU, node.CoprocessorCPU))
 	}
-	fmt.Printf("\n统计信息:\n")
-	fmt.Printf("  写热点 - 最大值: %.2f%%, 平均值: %.2f%%, 最高节点: %s\n",
-		monitor.MaxRaftstoreCPU, monitor.AvgRaftstoreCPU, monitor.WriteHotspotNode)
-	fmt.Printf("  读热点 - 最大值: %.2f%%, 平均值: %.2f%%, 最高节点: %s\n",
-		monitor.MaxCoprocessorCPU, monitor.AvgCoprocessorCPU, monitor.ReadHotspotNode)
+	fmt.Print(T("main.tidb.statistics"))
+	fmt.Print(T("main.tidb.write_statistics",
+		monitor.MaxRaftstoreCPU, monitor.AvgRaftstoreCPU, monitor.WriteHotspotNode))
+	fmt.Print(T("main.tidb.read_statistics",
+		monitor.MaxCoprocessorCPU, monitor.AvgCoprocessorCPU, monitor.ReadHotspotNode))
 
 	// 4. 执行规则
 	err = ruleExecutor.ExecuteWithLog(monitor)
 	if err != nil {
-		log.Fatalf("执行规则失败: %v", err)
+		log.Fatal(T("main.execute_failed", err))
 	}
 
 	// 5. 输出结果
-	fmt.Println("\n检测结果:")
+	fmt.Println(T("main.tidb.result"))
 	if monitor.WriteHotspotDetected {
-		fmt.Printf("  ✓ 检测到写热点！\n")
-		fmt.Printf("    热点节点: %s\n", monitor.WriteHotspotNode)
-		fmt.Printf("    Raftstore CPU: %.2f%% (平均值: %.2f%%)\n",
-			monitor.MaxRaftstoreCPU, monitor.AvgRaftstoreCPU)
-		fmt.Printf("    热点比例: %.2f 倍\n", monitor.WriteHotspotRatio)
+		fmt.Print(T("main.tidb.write_hotspot"))
+		fmt.Print(T("main.tidb.hotspot_node", monitor.WriteHotspotNode))
+		fmt.Print(T("main.tidb.raftstore_cpu",
+			monitor.MaxRaftstoreCPU, monitor.AvgRaftstoreCPU))
+		fmt.Print(T("main.tidb.hotspot_ratio", monitor.WriteHotspotRatio))
 
 		// 检查是否是非聚簇索引热点
 		if monitor.IsNonClusteredIndexHotspot && monitor.RecommendShardRowIDBits {
-			fmt.Printf("  ⚠ 非聚簇索引写入热点！\n")
-			fmt.Printf("    建议设置 SHARD_ROW_ID_BITS=%d 来打散 RowID，缓解写入热点问题\n", monitor.ShardRowIDBits)
-			fmt.Printf("    SQL 示例: ALTER TABLE table_name SHARD_ROW_ID_BITS = %d;\n", monitor.ShardRowIDBits)
+			fmt.Print(T("main.tidb.non_clustered_hotspot"))
+			fmt.Print(T("main.tidb.recommend_shard_full", monitor.ShardRowIDBits))
+			fmt.Print(T("main.tidb.shard_sql", monitor.ShardRowIDBits))
 		}
 	} else {
-		fmt.Printf("  ✗ 未检测到写热点\n")
+		fmt.Print(T("main.tidb.no_write_hotspot"))
 	}
 
 	if monitor.ReadHotspotDetected {
-		fmt.Printf("  ✓ 检测到读热点！\n")
-		fmt.Printf("    热点节点: %s\n", monitor.ReadHotspotNode)
-		fmt.Printf("    Coprocessor CPU: %.2f%% (平均值: %.2f%%)\n",
-			monitor.MaxCoprocessorCPU, monitor.AvgCoprocessorCPU)
-		fmt.Printf("    热点比例: %.2f 倍\n", monitor.ReadHotspotRatio)
+		fmt.Print(T("main.tidb.read_hotspot"))
+		fmt.Print(T("main.tidb.hotspot_node", monitor.ReadHotspotNode))
+		fmt.Print(T("main.tidb.coprocessor_cpu",
+			monitor.MaxCoprocessorCPU, monitor.AvgCoprocessorCPU))
+		fmt.Print(T("main.tidb.hotspot_ratio", monitor.ReadHotspotRatio))
 	} else {
-		fmt.Printf("  ✗ 未检测到读热点\n")
+		fmt.Print(T("main.tidb.no_read_hotspot"))
 	}
 
 	// 6. 演示非聚簇索引写入热点
-	fmt.Println("\n=== 测试非聚簇索引写入热点 ===")
+	fmt.Println(T("main.tidb.non_clustered_title"))
 	nonClusteredNodes := []*TiKVNode{
 		{NodeID: "tikv-1", RaftstoreCPU: 25.3, CoprocessorCPU: 22.1},
 		{NodeID: "tikv-2", RaftstoreCPU: 28.7, CoprocessorCPU: 24.5},
@@ -113,32 +161,32 @@ func tidbRuleExecutor() {
 	}
 
 	nonClusteredMonitor.CalculateStatistics()
-	fmt.Printf("统计信息: 写热点最大值=%.2f%%, 平均值=%.2f%%; 读热点最大值=%.2f%%, 平均值=%.2f%%\n",
+	fmt.Print(T("main.tidb.statistics_both",
 		nonClusteredMonitor.MaxRaftstoreCPU, nonClusteredMonitor.AvgRaftstoreCPU,
-		nonClusteredMonitor.MaxCoprocessorCPU, nonClusteredMonitor.AvgCoprocessorCPU)
+		nonClusteredMonitor.MaxCoprocessorCPU, nonClusteredMonitor.AvgCoprocessorCPU))
 
 	err = ruleExecutor.ExecuteWithLog(nonClusteredMonitor)
 	if err != nil {
-		log.Fatalf("执行规则失败: %v", err)
+		log.Fatal(T("main.execute_failed", err))
 	}
 
-	fmt.Println("\n检测结果:")
+	fmt.Println(T("main.tidb.result"))
 	if nonClusteredMonitor.WriteHotspotDetected {
-		fmt.Printf("  ✓ 检测到写热点\n")
+		fmt.Print(T("main.tidb.write_hotspot_found"))
 		if nonClusteredMonitor.RecommendShardRowIDBits {
-			fmt.Printf("  ⚠ 非聚簇索引写入热点！\n")
-			fmt.Printf("    建议设置 SHARD_ROW_ID_BITS=%d 来打散 RowID\n", nonClusteredMonitor.ShardRowIDBits)
-			fmt.Printf("    SQL 示例: ALTER TABLE table_name SHARD_ROW_ID_BITS = %d;\n", nonClusteredMonitor.ShardRowIDBits)
+			fmt.Print(T("main.tidb.non_clustered_hotspot"))
+			fmt.Print(T("main.tidb.recommend_shard_short", nonClusteredMonitor.ShardRowIDBits))
+			fmt.Print(T("main.tidb.shard_sql", nonClusteredMonitor.ShardRowIDBits))
 		}
 	} else {
-		fmt.Printf("  ✗ 未检测到写热点\n")
+		fmt.Print(T("main.tidb.no_write_hotspot"))
 	}
 
 	// 7. 演示规则不匹配的情况
-	fmt.Println("\n=== 测试规则不匹配的情况 ===")
+	fmt.Println(T("main.tidb.mismatch_title"))
 
 	// 场景1：写热点检测规则不匹配 - CPU 差异不够大
-	fmt.Println("\n场景1：写热点检测规则不匹配（CPU差异不够大）")
+	fmt.Println(T("main.tidb.case1_title"))
 	lowDiffNodes := []*TiKVNode{
 		{NodeID: "tikv-1", RaftstoreCPU: 30.0, CoprocessorCPU: 25.0},
 		{NodeID: "tikv-2", RaftstoreCPU: 32.0, CoprocessorCPU: 28.0},
@@ -155,31 +203,31 @@ func tidbRuleExecutor() {
 	}
 
 	lowDiffMonitor.CalculateStatistics()
-	fmt.Printf("统计信息: 写热点最大值=%.2f%%, 平均值=%.2f%%, 比例=%.2f倍\n",
+	fmt.Print(T("main.tidb.statistics_ratio",
 		lowDiffMonitor.MaxRaftstoreCPU, lowDiffMonitor.AvgRaftstoreCPU,
-		lowDiffMonitor.MaxRaftstoreCPU/lowDiffMonitor.AvgRaftstoreCPU)
-	fmt.Printf("说明: 热点比例 %.2f 倍 < 1.5 倍，DetectWriteHotspot 规则不会匹配\n",
-		lowDiffMonitor.MaxRaftstoreCPU/lowDiffMonitor.AvgRaftstoreCPU)
+		lowDiffMonitor.MaxRaftstoreCPU/lowDiffMonitor.AvgRaftstoreCPU))
+	fmt.Print(T("main.tidb.ratio_below_threshold",
+		lowDiffMonitor.MaxRaftstoreCPU/lowDiffMonitor.AvgRaftstoreCPU))
 
 	err = ruleExecutor.ExecuteWithLog(lowDiffMonitor)
 	if err != nil {
-		log.Fatalf("执行规则失败: %v", err)
+		log.Fatal(T("main.execute_failed", err))
 	}
 
-	fmt.Println("\n检测结果:")
+	fmt.Println(T("main.tidb.result"))
 	if lowDiffMonitor.WriteHotspotDetected {
-		fmt.Printf("  ✓ 检测到写热点\n")
+		fmt.Print(T("main.tidb.write_hotspot_found"))
 	} else {
-		fmt.Printf("  ✗ 未检测到写热点（规则未匹配：CPU差异不够大）\n")
+		fmt.Print(T("main.tidb.no_write_hotspot_low_diff"))
 	}
 	if lowDiffMonitor.RecommendShardRowIDBits {
-		fmt.Printf("  ⚠ 建议设置 SHARD_ROW_ID_BITS\n")
+		fmt.Print(T("main.tidb.recommend_shard"))
 	} else {
-		fmt.Printf("  ✗ 未建议设置 SHARD_ROW_ID_BITS（因为未检测到写热点）\n")
+		fmt.Print(T("main.tidb.no_shard_no_hotspot"))
 	}
 
 	// 场景2：非聚簇索引建议规则不匹配 - 检测到写热点但不是非聚簇索引热点
-	fmt.Println("\n场景2：非聚簇索引建议规则不匹配（不是非聚簇索引热点）")
+	fmt.Println(T("main.tidb.case2_title"))
 	normalHotspotNodes := []*TiKVNode{
 		{NodeID: "tikv-1", RaftstoreCPU: 30.0, CoprocessorCPU: 25.0},
 		{NodeID: "tikv-2", RaftstoreCPU: 32.0, CoprocessorCPU: 28.0},
@@ -196,30 +244,30 @@ func tidbRuleExecutor() {
 	}
 
 	normalHotspotMonitor.CalculateStatistics()
-	fmt.Printf("统计信息: 写热点最大值=%.2f%%, 平均值=%.2f%%, 比例=%.2f倍\n",
+	fmt.Print(T("main.tidb.statistics_ratio",
 		normalHotspotMonitor.MaxRaftstoreCPU, normalHotspotMonitor.AvgRaftstoreCPU,
-		normalHotspotMonitor.MaxRaftstoreCPU/normalHotspotMonitor.AvgRaftstoreCPU)
-	fmt.Printf("说明: IsNonClusteredIndexHotspot = false，RecommendShardRowIDBits* 规则不会匹配\n")
+		normalHotspotMonitor.MaxRaftstoreCPU/normalHotspotMonitor.AvgRaftstoreCPU))
+	fmt.Print(T("main.tidb.case2_note"))
 
 	err = ruleExecutor.ExecuteWithLog(normalHotspotMonitor)
 	if err != nil {
-		log.Fatalf("执行规则失败: %v", err)
+		log.Fatal(T("main.execute_failed", err))
 	}
 
-	fmt.Println("\n检测结果:")
+	fmt.Println(T("main.tidb.result"))
 	if normalHotspotMonitor.WriteHotspotDetected {
-		fmt.Printf("  ✓ 检测到写热点\n")
+		fmt.Print(T("main.tidb.write_hotspot_found"))
 	} else {
-		fmt.Printf("  ✗ 未检测到写热点\n")
+		fmt.Print(T("main.tidb.no_write_hotspot"))
 	}
 	if normalHotspotMonitor.RecommendShardRowIDBits {
-		fmt.Printf("  ⚠ 建议设置 SHARD_ROW_ID_BITS=%d\n", normalHotspotMonitor.ShardRowIDBits)
+		fmt.Print(T("main.tidb.recommend_shard_bits", normalHotspotMonitor.ShardRowIDBits))
 	} else {
-		fmt.Printf("  ✗ 未建议设置 SHARD_ROW_ID_BITS（规则未匹配：不是非聚簇索引热点）\n")
+		fmt.Print(T("main.tidb.no_shard_clustered"))
 	}
 
 	// 场景3：非聚簇索引建议规则不匹配 - 热点比例不在建议范围内
-	fmt.Println("\n场景3：非聚簇索引建议规则不匹配（热点比例不在建议范围内）")
+	fmt.Println(T("main.tidb.case3_title"))
 	edgeCaseNodes := []*TiKVNode{
 		{NodeID: "tikv-1", RaftstoreCPU: 30.0, CoprocessorCPU: 25.0},
 		{NodeID: "tikv-2", RaftstoreCPU: 32.0, CoprocessorCPU: 28.0},
@@ -237,31 +285,31 @@ func tidbRuleExecutor() {
 
 	edgeCaseMonitor.CalculateStatistics()
 	ratio := edgeCaseMonitor.MaxRaftstoreCPU / edgeCaseMonitor.AvgRaftstoreCPU
-	fmt.Printf("统计信息: 写热点最大值=%.2f%%, 平均值=%.2f%%, 比例=%.2f倍\n",
-		edgeCaseMonitor.MaxRaftstoreCPU, edgeCaseMonitor.AvgRaftstoreCPU, ratio)
-	fmt.Printf("说明: 热点比例 %.2f 倍 < 1.5 倍，DetectWriteHotspot 规则不会匹配\n", ratio)
-	fmt.Printf("      因此 RecommendShardRowIDBits* 规则也不会匹配\n")
+	fmt.Print(T("main.tidb.statistics_ratio",
+		edgeCaseMonitor.MaxRaftstoreCPU, edgeCaseMonitor.AvgRaftstoreCPU, ratio))
+	fmt.Print(T("main.tidb.ratio_below_threshold", ratio))
+	fmt.Print(T("main.tidb.case3_note"))
 
 	err = ruleExecutor.ExecuteWithLog(edgeCaseMonitor)
 	if err != nil {
-		log.Fatalf("执行规则失败: %v", err)
+		log.Fatal(T("main.execute_failed", err))
 	}
 
-	fmt.Println("\n检测结果:")
+	fmt.Println(T("main.tidb.result"))
 	if edgeCaseMonitor.WriteHotspotDetected {
-		fmt.Printf("  ✓ 检测到写热点\n")
+		fmt.Print(T("main.tidb.write_hotspot_found"))
 		if edgeCaseMonitor.RecommendShardRowIDBits {
-			fmt.Printf("  ⚠ 建议设置 SHARD_ROW_ID_BITS=%d\n", edgeCaseMonitor.ShardRowIDBits)
+			fmt.Print(T("main.tidb.recommend_shard_bits", edgeCaseMonitor.ShardRowIDBits))
 		} else {
-			fmt.Printf("  ✗ 未建议设置 SHARD_ROW_ID_BITS\n")
+			fmt.Print(T("main.tidb.no_shard"))
 		}
 	} else {
-		fmt.Printf("  ✗ 未检测到写热点（规则未匹配：热点比例 %.2f 倍 < 1.5 倍）\n", ratio)
-		fmt.Printf("  ✗ 未建议设置 SHARD_ROW_ID_BITS（因为未检测到写热点）\n")
+		fmt.Print(T("main.tidb.no_write_hotspot_ratio", ratio))
+		fmt.Print(T("main.tidb.no_shard_no_hotspot"))
 	}
 
 	// 8. 演示正常情况
-	fmt.Println("\n=== 测试正常情况（无热点）===")
+	fmt.Println(T("main.tidb.normal_title"))
 	normalNodes := []*TiKVNode{
 		{NodeID: "tikv-1", RaftstoreCPU: 30.5, CoprocessorCPU: 25.3},
 		{NodeID: "tikv-2", RaftstoreCPU: 32.1, CoprocessorCPU: 28.7},
@@ -277,24 +325,24 @@ func tidbRuleExecutor() {
 	}
 
 	normalMonitor.CalculateStatistics()
-	fmt.Printf("统计信息: 写热点最大值=%.2f%%, 平均值=%.2f%%; 读热点最大值=%.2f%%, 平均值=%.2f%%\n",
+	fmt.Print(T("main.tidb.statistics_both",
 		normalMonitor.MaxRaftstoreCPU, normalMonitor.AvgRaftstoreCPU,
-		normalMonitor.MaxCoprocessorCPU, normalMonitor.AvgCoprocessorCPU)
+		normalMonitor.MaxCoprocessorCPU, normalMonitor.AvgCoprocessorCPU))
 
 	err = ruleExecutor.ExecuteWithLog(normalMonitor)
 	if err != nil {
-		log.Fatalf("执行规则失败: %v", err)
+		log.Fatal(T("main.execute_failed", err))
 	}
 
-	fmt.Println("\n检测结果:")
+	fmt.Println(T("main.tidb.result"))
 	if normalMonitor.WriteHotspotDetected {
-		fmt.Printf("  ✓ 检测到写热点\n")
+		fmt.Print(T("main.tidb.write_hotspot_found"))
 	} else {
-		fmt.Printf("  ✗ 未检测到写热点（正常）\n")
+		fmt.Print(T("main.tidb.no_write_hotspot_normal"))
 	}
 	if normalMonitor.ReadHotspotDetected {
-		fmt.Printf("  ✓ 检测到读热点\n")
+		fmt.Print(T("main.tidb.read_hotspot_found"))
 	} else {
-		fmt.Printf("  ✗ 未检测到读热点（正常）\n")
+		fmt.Print(T("main.tidb.no_read_hotspot_normal"))
 	}
 }
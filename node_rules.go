@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+)
+
+// 节点级规则在数据上下文中的事实名称
+const (
+	tikvNodeFact   = "TiKVNode"
+	nodeResultFact = "NodeResult"
+)
+
+// tikvNodeExecutorFacts 节点级规则执行器向数据上下文提供的事实
+var tikvNodeExecutorFacts = []string{tidbMonitorFact, tikvNodeFact, nodeResultFact}
+
+// NodeResult 节点级规则的输出，每个 TiKV 节点一份，规则不修改集群级的 TiDBMonitor：
+//
+//	when
+//	    TiKVNode.CoprocessorCPU > Stats.Percentile("CoprocessorCPU", 50) * 2
+//	then
+//	    NodeResult.AddFinding("node_coprocessor_outlier", TiKVNode.CoprocessorCPU / Stats.Percentile("CoprocessorCPU", 50));
+type NodeResult struct {
+	NodeID     string
+	Locale     string
+	Findings   []Finding     // 节点级诊断结论，Target 为节点 ID
+	Messages   []RuleMessage // 规则输出的消息
+	FiredRules []string      // 在该节点上触发的规则
+
+	err error
+}
+
+// AddFinding 记录节点级诊断结论，value 可以是 GRL 中的整数或浮点数
+func (result *NodeResult) AddFinding(findingType string, value interface{}) {
+	reflected := reflect.ValueOf(value)
+	if !reflected.IsValid() || !isNumericKind(reflected.Kind()) {
+		if result.err == nil {
+			result.err = fmt.Errorf("NodeResult: 结论 %s 的数值 %v 不是数值", findingType, value)
+		}
+		return
+	}
+	result.Findings = append(result.Findings, Finding{Type: findingType, Target: result.NodeID, Value: numericValue(reflected)})
+}
+
+// HasFinding 节点是否已有该类型的结论，用于规则之间的互斥
+func (result *NodeResult) HasFinding(findingType string) bool {
+	for _, finding := range result.Findings {
+		if finding.Type == findingType {
+			return true
+		}
+	}
+	return false
+}
+
+// Message 按 Locale 翻译消息并记录，同时输出到规则日志
+func (result *NodeResult) Message(key string, args ...interface{}) {
+	text := Translate(result.Locale, key, args...)
+	result.Messages = append(result.Messages, RuleMessage{Key: key, Args: args, Text: text})
+	ast.GrlLogger.Println(text)
+}
+
+// Err 规则执行中遇到的第一个错误（如非数值的结论）
+func (result *NodeResult) Err() error {
+	return result.err
+}
+
+// clone 深拷贝节点结果
+func (result *NodeResult) clone() *NodeResult {
+	cloned := *result
+	cloned.Findings = append([]Finding(nil), result.Findings...)
+	cloned.Messages = append([]RuleMessage(nil), result.Messages...)
+	cloned.FiredRules = append([]string(nil), result.FiredRules...)
+	return &cloned
+}
+
+// TiKVNodeRuleExecutor 节点级规则执行器：对每个 TiKV 节点执行一次规则，
+// 数据上下文中包含当前节点 TiKVNode、集群级的 TiDBMonitor、集群聚合 Stats 以及该节点的 NodeResult
+type TiKVNodeRuleExecutor struct {
+	*RuleExecutor
+}
+
+// NewTiKVNodeRuleExecutorWithPack 使用规则包（及其依赖）创建节点级规则执行器，extraRuleFiles 为额外加载的磁盘规则文件
+func NewTiKVNodeRuleExecutorWithPack(packName string, extraRuleFiles []string, ruleName, ruleVersion string) (*TiKVNodeRuleExecutor, error) {
+	return NewTiKVNodeRuleExecutorWithRuleSet(&RuleSet{Packs: []string{packName}, Files: extraRuleFiles}, ruleName, ruleVersion)
+}
+
+// NewTiKVNodeRuleExecutorWithRuleSet 创建节点级规则执行器，规则包需要声明 TiKVNode 和 NodeResult 事实
+func NewTiKVNodeRuleExecutorWithRuleSet(ruleSet *RuleSet, ruleName, ruleVersion string) (*TiKVNodeRuleExecutor, error) {
+	executor, err := NewRuleExecutor(ruleSet, ruleName, ruleVersion, tikvNodeExecutorFacts...)
+	if err != nil {
+		return nil, err
+	}
+	executor.AddDerivedFact(statsFact, func(facts Facts) interface{} {
+		return NewNodeStats(facts[tidbMonitorFact].(*TiDBMonitor))
+	})
+	return &TiKVNodeRuleExecutor{RuleExecutor: executor}, nil
+}
+
+// EvaluateNode 对单个节点执行规则
+func (executor *TiKVNodeRuleExecutor) EvaluateNode(monitor *TiDBMonitor, node *TiKVNode) (*NodeResult, error) {
+	result := &NodeResult{NodeID: node.NodeID, Locale: monitor.Locale}
+	firedRules, err := executor.ExecuteWithTrace(Facts{
+		tidbMonitorFact: monitor,
+		tikvNodeFact:    node,
+		nodeResultFact:  result,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("节点 %s: %v", node.NodeID, err)
+	}
+	if result.Err() != nil {
+		return nil, fmt.Errorf("节点 %s: 执行规则失败: %v", node.NodeID, result.Err())
+	}
+	result.FiredRules = firedRules
+	return result, nil
+}
+
+// EvaluateNodes 依次对 monitor.TiKVNodes 中的每个节点执行规则，结果按节点顺序保存到 monitor.NodeResults，
+// 节点级结论会出现在 Findings 和诊断报告中。monitor 需要已经计算统计信息（一般在集群级规则之后执行）
+func (executor *TiKVNodeRuleExecutor) EvaluateNodes(monitor *TiDBMonitor) ([]*NodeResult, error) {
+	results := make([]*NodeResult, 0, len(monitor.TiKVNodes))
+	for _, node := range monitor.TiKVNodes {
+		result, err := executor.EvaluateNode(monitor, node)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	monitor.NodeResults = results
+	return results, nil
+}
+
+// NodeFindings 所有节点级诊断结论，按节点顺序排列
+func (monitor *TiDBMonitor) NodeFindings() []Finding {
+	var findings []Finding
+	for _, result := range monitor.NodeResults {
+		findings = append(findings, result.Findings...)
+	}
+	return findings
+}
+
+// NodeFiredRules 在任意节点上触发过的节点级规则（去重，按首次触发顺序）
+func (monitor *TiDBMonitor) NodeFiredRules() []string {
+	var firedRules []string
+	seen := make(map[string]bool)
+	for _, result := range monitor.NodeResults {
+		for _, ruleName := range result.FiredRules {
+			if !seen[ruleName] {
+				seen[ruleName] = true
+				firedRules = append(firedRules, ruleName)
+			}
+		}
+	}
+	return firedRules
+}
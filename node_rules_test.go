@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTiKVNodeRules(t *testing.T) {
+	executor, err := NewTiKVNodeRuleExecutorWithPack("tikv-node", nil, "TiKVNode", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化节点级规则执行器失败: %v", err)
+	}
+
+	monitor := &TiDBMonitor{
+		Locale:            LocaleEnUS,
+		CheckWriteHotspot: true,
+		CheckReadHotspot:  true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 30, CoprocessorCPU: 25},
+			{NodeID: "tikv-2", RaftstoreCPU: 32, CoprocessorCPU: 28},
+			{NodeID: "tikv-3", RaftstoreCPU: 85, CoprocessorCPU: 22},
+			{NodeID: "tikv-4", RaftstoreCPU: 29, CoprocessorCPU: 70},
+			{NodeID: "tikv-5", RaftstoreCPU: 31, CoprocessorCPU: 80},
+		},
+	}
+	monitor.CalculateStatistics()
+
+	results, err := executor.EvaluateNodes(monitor)
+	if err != nil {
+		t.Fatalf("执行节点级规则失败: %v", err)
+	}
+	if len(results) != 5 || len(monitor.NodeResults) != 5 {
+		t.Fatalf("每个节点应有一份结果: %d", len(results))
+	}
+	if len(results[0].Findings) != 0 || len(results[0].FiredRules) != 0 {
+		t.Errorf("tikv-1 不应有结论: %+v", results[0])
+	}
+
+	// 每次执行使用独立的知识库实例，Retract 只在当前节点内生效
+	want := map[string]float64{
+		"node_raftstore_outlier@tikv-3":   85.0 / 31,
+		"node_coprocessor_outlier@tikv-4": 70.0 / 28,
+		"node_coprocessor_outlier@tikv-5": 80.0 / 28,
+	}
+	findings := monitor.NodeFindings()
+	if len(findings) != len(want) {
+		t.Fatalf("节点级结论不符: %v", findings)
+	}
+	for _, finding := range findings {
+		if math.Abs(finding.Value-want[finding.Key()]) > 1e-9 {
+			t.Errorf("结论 %s 不符，期望 %.4f", finding, want[finding.Key()])
+		}
+	}
+	if got := strings.Join(monitor.NodeFiredRules(), ","); got != "DetectRaftstoreOutlierNode,DetectCoprocessorOutlierNode" {
+		t.Errorf("触发的节点级规则不符: %s", got)
+	}
+	if text := results[3].Messages[0].Text; text != "Coprocessor CPU of tikv-4 is 2.50x the cluster median, reads are concentrated on this store" {
+		t.Errorf("节点消息未按 en-US 翻译: %s", text)
+	}
+
+	// 节点级结论与集群级结论一起出现在 Findings 和报告中
+	var keys []string
+	for _, finding := range monitor.Findings() {
+		keys = append(keys, finding.Key())
+	}
+	if !strings.Contains(strings.Join(keys, ","), "node_coprocessor_outlier@tikv-4") {
+		t.Errorf("Findings 中缺少节点级结论: %v", keys)
+	}
+	report := NewDiagnosticReport(monitor, nil, nil)
+	var markdown bytes.Buffer
+	if err := report.Write(&markdown, ReportFormatMarkdown); err != nil {
+		t.Fatalf("渲染报告失败: %v", err)
+	}
+	if !strings.Contains(markdown.String(), "Coprocessor CPU of tikv-5 is 2.86x the cluster median") {
+		t.Errorf("报告中缺少节点级结论:\n%s", markdown.String())
+	}
+
+	cloned := monitor.Clone()
+	cloned.NodeResults[3].Findings[0].Value = 0
+	if monitor.NodeResults[3].Findings[0].Value == 0 {
+		t.Errorf("Clone 应深拷贝节点结果")
+	}
+
+	// 节点级规则包需要 TiKVNode / NodeResult 事实，不能加载到集群级执行器
+	if _, err := NewTiDBRuleExecutorWithPack("tikv-node", nil, "TiKVNode", "1.0.0"); err == nil {
+		t.Errorf("集群级执行器不应加载节点级规则包")
+	}
+}
+
+func TestTiKVNodeRulesCustomFile(t *testing.T) {
+	dir := t.TempDir()
+	ruleFile := filepath.Join(dir, "node.grl")
+	rules := `rule SmallNode "节点 CPU 核数低于集群最大值" salience 10 {
+    when
+        TiKVNode.CPUCores < Stats.Max("CPUCores") &&
+        NodeResult.HasFinding("small_node") == false
+    then
+        NodeResult.AddFinding("small_node", TiKVNode.CPUCores);
+        Retract("SmallNode");
+}
+`
+	if err := os.WriteFile(ruleFile, []byte(rules), 0o644); err != nil {
+		t.Fatalf("写入规则文件失败: %v", err)
+	}
+	executor, err := NewTiKVNodeRuleExecutorWithRuleSet(&RuleSet{Files: []string{ruleFile}}, "Node", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化节点级规则执行器失败: %v", err)
+	}
+	monitor := &TiDBMonitor{TiKVNodes: []*TiKVNode{
+		{NodeID: "a", CPUCores: 16}, {NodeID: "b", CPUCores: 8}, {NodeID: "c", CPUCores: 16},
+	}}
+	if _, err := executor.EvaluateNodes(monitor); err != nil {
+		t.Fatalf("执行节点级规则失败: %v", err)
+	}
+	findings := monitor.NodeFindings()
+	if len(findings) != 1 || findings[0].Key() != "small_node@b" || findings[0].Value != 8 {
+		t.Errorf("整数结论值不符: %v", findings)
+	}
+
+	bad := filepath.Join(dir, "bad.grl")
+	if err := os.WriteFile(bad, []byte(`rule BadValue "非数值结论" {
+    when
+        TiKVNode.NodeID == "b"
+    then
+        NodeResult.AddFinding("bad", TiKVNode.NodeID);
+        Retract("BadValue");
+}
+`), 0o644); err != nil {
+		t.Fatalf("写入规则文件失败: %v", err)
+	}
+	executor, err = NewTiKVNodeRuleExecutorWithRuleSet(&RuleSet{Files: []string{bad}}, "Node", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化节点级规则执行器失败: %v", err)
+	}
+	if _, err := executor.EvaluateNodes(monitor); err == nil || !strings.Contains(err.Error(), "节点 b") {
+		t.Errorf("非数值结论应返回带节点 ID 的错误: %v", err)
+	}
+}
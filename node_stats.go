@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// statsFact 聚合函数在数据上下文中的名称，与 TiDBMonitor 一起加入数据上下文
+const statsFact = "Stats"
+
+// 派生指标：按 NormalizeCPU 取原始 CPU 或线程池利用率（与 CalculateStatistics 使用的值一致）
+const (
+	metricRaftstoreLoad   = "RaftstoreLoad"
+	metricCoprocessorLoad = "CoprocessorLoad"
+)
+
+// NodeStats 对 TiKVNodes 的聚合查询，在 GRL 中以 Stats 调用，规则作者不需要修改 TiDBMonitor 就能写新的聚合条件：
+//
+//	when
+//	    Stats.CountAbove("RaftstoreCPU", 80) >= 2 &&
+//	    Stats.Percentile("CoprocessorCPU", 90) > Stats.Avg("CoprocessorCPU") * 2
+//	then
+//	    TiDBMonitor.Message("...", Stats.NodeWithMax("RaftstoreCPU"));
+//
+// 指标为 TiKVNode 的数值字段（如 RaftstoreCPU、ApplyCPU、CPUCores）、无参数的数值方法（如 RaftstoreUtilization），
+// 或 RaftstoreLoad / CoprocessorLoad。GRL 函数无法返回错误，未知指标返回 0 并记录在 Err 中，执行结束后作为执行错误返回
+type NodeStats struct {
+	monitor *TiDBMonitor
+	err     error
+}
+
+// NewNodeStats 创建 monitor.TiKVNodes 的聚合查询
+func NewNodeStats(monitor *TiDBMonitor) *NodeStats {
+	return &NodeStats{monitor: monitor}
+}
+
+// Err 规则执行中遇到的第一个错误（如未知指标）
+func (stats *NodeStats) Err() error {
+	return stats.err
+}
+
+// metric 解析指标名称，返回从节点取值的函数
+func (stats *NodeStats) metric(name string) (func(node *TiKVNode) float64, bool) {
+	switch name {
+	case metricRaftstoreLoad:
+		return stats.monitor.raftstoreLoad, true
+	case metricCoprocessorLoad:
+		return stats.monitor.coprocessorLoad, true
+	}
+
+	nodeType := reflect.TypeOf(&TiKVNode{})
+	if field, ok := nodeType.Elem().FieldByName(name); ok && isNumericKind(field.Type.Kind()) {
+		return func(node *TiKVNode) float64 {
+			return numericValue(reflect.ValueOf(node).Elem().FieldByIndex(field.Index))
+		}, true
+	}
+	if method, ok := nodeType.MethodByName(name); ok && method.Type.NumIn() == 1 &&
+		method.Type.NumOut() == 1 && isNumericKind(method.Type.Out(0).Kind()) {
+		return func(node *TiKVNode) float64 {
+			return numericValue(method.Func.Call([]reflect.Value{reflect.ValueOf(node)})[0])
+		}, true
+	}
+	return nil, false
+}
+
+// values 所有节点的指标值（与 TiKVNodes 顺序一致），未知指标时记录错误并返回 nil
+func (stats *NodeStats) values(name string) []float64 {
+	metric, ok := stats.metric(name)
+	if !ok {
+		if stats.err == nil {
+			stats.err = fmt.Errorf("Stats: 未知的 TiKV 节点指标 %q", name)
+		}
+		return nil
+	}
+	values := make([]float64, len(stats.monitor.TiKVNodes))
+	for i, node := range stats.monitor.TiKVNodes {
+		values[i] = metric(node)
+	}
+	return values
+}
+
+// Count TiKV 节点数
+func (stats *NodeStats) Count() int {
+	return len(stats.monitor.TiKVNodes)
+}
+
+// Sum 指标之和
+func (stats *NodeStats) Sum(metric string) float64 {
+	var sum float64
+	for _, value := range stats.values(metric) {
+		sum += value
+	}
+	return sum
+}
+
+// Avg 指标平均值，没有节点时为 0
+func (stats *NodeStats) Avg(metric string) float64 {
+	values := stats.values(metric)
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// Max 指标最大值，没有节点时为 0
+func (stats *NodeStats) Max(metric string) float64 {
+	_, value := stats.extreme(metric, func(a, b float64) bool { return a > b })
+	return value
+}
+
+// Min 指标最小值，没有节点时为 0
+func (stats *NodeStats) Min(metric string) float64 {
+	_, value := stats.extreme(metric, func(a, b float64) bool { return a < b })
+	return value
+}
+
+// NodeWithMax 指标最大的节点 ID，相同时取靠前的节点，没有节点时为空
+func (stats *NodeStats) NodeWithMax(metric string) string {
+	node, _ := stats.extreme(metric, func(a, b float64) bool { return a > b })
+	return node
+}
+
+// NodeWithMin 指标最小的节点 ID，相同时取靠前的节点，没有节点时为空
+func (stats *NodeStats) NodeWithMin(metric string) string {
+	node, _ := stats.extreme(metric, func(a, b float64) bool { return a < b })
+	return node
+}
+
+// Percentile 指标的百分位数（0-100），在相邻的两个值之间线性插值，没有节点时为 0
+func (stats *NodeStats) Percentile(metric string, percentile interface{}) float64 {
+	p := stats.number(percentile)
+	values := stats.values(metric)
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	rank := math.Max(0, math.Min(100, p)) / 100 * float64(len(values)-1)
+	lower, upper := int(math.Floor(rank)), int(math.Ceil(rank))
+	return values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+}
+
+// CountAbove 指标大于阈值的节点数
+func (stats *NodeStats) CountAbove(metric string, threshold interface{}) int {
+	limit := stats.number(threshold)
+	count := 0
+	for _, value := range stats.values(metric) {
+		if value > limit {
+			count++
+		}
+	}
+	return count
+}
+
+// CountBelow 指标小于阈值的节点数
+func (stats *NodeStats) CountBelow(metric string, threshold interface{}) int {
+	limit := stats.number(threshold)
+	count := 0
+	for _, value := range stats.values(metric) {
+		if value < limit {
+			count++
+		}
+	}
+	return count
+}
+
+// NodesAbove 指标大于阈值的节点 ID，以逗号分隔，可以直接用于规则消息
+func (stats *NodeStats) NodesAbove(metric string, threshold interface{}) string {
+	limit := stats.number(threshold)
+	var nodes []string
+	for i, value := range stats.values(metric) {
+		if value > limit {
+			nodes = append(nodes, stats.monitor.TiKVNodes[i].NodeID)
+		}
+	}
+	return strings.Join(nodes, ",")
+}
+
+// extreme 按 better 比较找出最值节点及其指标值，没有节点或指标未知时为空和 0
+func (stats *NodeStats) extreme(metric string, better func(a, b float64) bool) (string, float64) {
+	index := -1
+	values := stats.values(metric)
+	for i, value := range values {
+		if index < 0 || better(value, values[index]) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return "", 0
+	}
+	return stats.monitor.TiKVNodes[index].NodeID, values[index]
+}
+
+// number 将 GRL 传入的数值参数转换为 float64：GRL 中的 80 为 int64、80.0 为 float64，
+// Grule 调用函数时不做类型转换，因此阈值参数声明为 interface{}
+func (stats *NodeStats) number(value interface{}) float64 {
+	reflected := reflect.ValueOf(value)
+	if !reflected.IsValid() || !isNumericKind(reflected.Kind()) {
+		if stats.err == nil {
+			stats.err = fmt.Errorf("Stats: 参数 %v 不是数值", value)
+		}
+		return 0
+	}
+	return numericValue(reflected)
+}
+
+// isNumericKind 是否为可以作为指标的数值类型
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// numericValue 将数值转换为 float64
+func numericValue(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	default:
+		return float64(value.Int())
+	}
+}
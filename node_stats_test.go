@@ -0,0 +1,137 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNodeStats(t *testing.T) {
+	monitor := &TiDBMonitor{
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 40, CoprocessorCPU: 10, CPUCores: 8},
+			{NodeID: "tikv-2", RaftstoreCPU: 90, CoprocessorCPU: 20, CPUCores: 8},
+			{NodeID: "tikv-3", RaftstoreCPU: 20, CoprocessorCPU: 30, CPUCores: 16},
+			{NodeID: "tikv-4", RaftstoreCPU: 90, CoprocessorCPU: 40, CPUCores: 16},
+		},
+	}
+	stats := NewNodeStats(monitor)
+
+	for name, got := range map[string]float64{
+		"Max":           stats.Max("RaftstoreCPU"),
+		"Min":           stats.Min("RaftstoreCPU"),
+		"Avg":           stats.Avg("RaftstoreCPU"),
+		"Sum":           stats.Sum("CoprocessorCPU"),
+		"P50":           stats.Percentile("CoprocessorCPU", 50),
+		"P90":           stats.Percentile("CoprocessorCPU", 90),
+		"P100":          stats.Percentile("RaftstoreCPU", 150),
+		"CPUCores":      stats.Max("CPUCores"),
+		"Utilization":   stats.Max("RaftstoreUtilization"),
+		"RaftstoreLoad": stats.Max("RaftstoreLoad"),
+	} {
+		want := map[string]float64{"Max": 90, "Min": 20, "Avg": 60, "Sum": 100, "P50": 25, "P90": 37,
+			"P100": 90, "CPUCores": 16, "Utilization": 0.45, "RaftstoreLoad": 90}[name]
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %.4f，期望 %.4f", name, got, want)
+		}
+	}
+	if stats.Count() != 4 || stats.CountAbove("RaftstoreCPU", 40) != 2 || stats.CountBelow("CoprocessorCPU", 30) != 2 {
+		t.Errorf("计数不符")
+	}
+	if stats.NodeWithMax("RaftstoreCPU") != "tikv-2" || stats.NodeWithMin("CoprocessorCPU") != "tikv-1" ||
+		stats.NodesAbove("RaftstoreCPU", 50) != "tikv-2,tikv-4" {
+		t.Errorf("节点查询不符")
+	}
+	if stats.Err() != nil {
+		t.Fatalf("不应有错误: %v", stats.Err())
+	}
+
+	if stats.Max("NodeID") != 0 || stats.Err() == nil || !strings.Contains(stats.Err().Error(), "NodeID") {
+		t.Errorf("非数值字段应记录错误: %v", stats.Err())
+	}
+	if empty := NewNodeStats(&TiDBMonitor{}); empty.Max("RaftstoreCPU") != 0 || empty.Percentile("RaftstoreCPU", 90) != 0 ||
+		empty.NodeWithMax("RaftstoreCPU") != "" {
+		t.Errorf("没有节点时应返回零值")
+	}
+}
+
+func TestNodeStatsInRules(t *testing.T) {
+	dir := t.TempDir()
+	ruleFile := filepath.Join(dir, "stats.grl")
+	rules := `rule MultipleHotWriteNodes "多个节点 Raftstore CPU 超过 80%" salience 10 {
+    when
+        Stats.CountAbove("RaftstoreCPU", 80) >= 2 &&
+        Stats.Percentile("RaftstoreCPU", 50) < Stats.Max("RaftstoreCPU") / 2
+    then
+        TiDBMonitor.WriteHotspotNode = Stats.NodesAbove("RaftstoreCPU", 80);
+        TiDBMonitor.Message("msg.write_hotspot.detected", Stats.NodeWithMax("RaftstoreCPU"));
+        Retract("MultipleHotWriteNodes");
+}
+`
+	if err := os.WriteFile(ruleFile, []byte(rules), 0o644); err != nil {
+		t.Fatalf("写入规则文件失败: %v", err)
+	}
+	executor, err := NewTiDBRuleExecutor(ruleFile, "Stats", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+
+	monitor := &TiDBMonitor{
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 20},
+			{NodeID: "tikv-2", RaftstoreCPU: 95},
+			{NodeID: "tikv-3", RaftstoreCPU: 25},
+			{NodeID: "tikv-4", RaftstoreCPU: 85},
+			{NodeID: "tikv-5", RaftstoreCPU: 30},
+		},
+	}
+	firedRules, err := executor.ExecuteWithTrace(monitor)
+	if err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if strings.Join(firedRules, ",") != "MultipleHotWriteNodes" || monitor.WriteHotspotNode != "tikv-2,tikv-4" ||
+		len(monitor.Messages) != 1 || monitor.Messages[0].Args[0] != "tikv-2" {
+		t.Errorf("规则结果不符: %v %s %+v", firedRules, monitor.WriteHotspotNode, monitor.Messages)
+	}
+
+	// 批量评估中每组事实使用独立的 Stats
+	results := executor.EvaluateBatch([]*TiDBMonitor{
+		{TiKVNodes: []*TiKVNode{
+			{NodeID: "a", RaftstoreCPU: 90}, {NodeID: "b", RaftstoreCPU: 90},
+			{NodeID: "c", RaftstoreCPU: 10}, {NodeID: "d", RaftstoreCPU: 10}, {NodeID: "e", RaftstoreCPU: 10},
+		}},
+		{TiKVNodes: []*TiKVNode{{NodeID: "a", RaftstoreCPU: 90}, {NodeID: "b", RaftstoreCPU: 10}}},
+	}, 2)
+	var fired []string
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("批量评估失败: %v", result.Err)
+		}
+		fired = append(fired, strings.Join(result.FiredRules, ","))
+	}
+	sort.Strings(fired)
+	if strings.Join(fired, "|") != "|MultipleHotWriteNodes" {
+		t.Errorf("批量评估结果不符: %v", fired)
+	}
+
+	unknown := filepath.Join(dir, "unknown.grl")
+	if err := os.WriteFile(unknown, []byte(`rule UnknownMetric "未知指标" {
+    when
+        Stats.Max("DiskIO") > 0
+    then
+        Retract("UnknownMetric");
+}
+`), 0o644); err != nil {
+		t.Fatalf("写入规则文件失败: %v", err)
+	}
+	executor, err = NewTiDBRuleExecutor(unknown, "Stats", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	if err := executor.Execute(monitor); err == nil || !strings.Contains(err.Error(), "DiskIO") {
+		t.Errorf("未知指标应返回执行错误: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import "fmt"
+
+// ReplayResult 一条历史记录使用新版本规则重新评估的结果
+type ReplayResult struct {
+	Record     *IncidentRecord
+	FiredRules []string
+	Findings   []Finding
+	Diffs      []FindingDiff // 相对记录中实际得到的结论：added 为新版本新增，removed 为新版本不再输出
+	Ignored    []Finding     // 记录中来自新版本没有加载的规则包的结论，不参与对比
+	Err        error
+}
+
+// HasDiff 新版本的结论与记录中的结论是否存在差异
+func (result *ReplayResult) HasDiff() bool {
+	return len(result.Diffs) > 0
+}
+
+// ReplaySummary 回放结果的汇总
+type ReplaySummary struct {
+	Records         int // 回放的记录数
+	Changed         int // 结论有差异的记录数
+	Failed          int // 新版本执行失败的记录数
+	Added           int
+	Removed         int
+	ChangedFindings int
+	Ignored         int // 不参与对比的结论数
+}
+
+// replayScope 回放的对比范围：新版本加载的规则包名称（不区分版本）及这些规则包声明的结论类型
+type replayScope struct {
+	packs    map[string]bool
+	findings map[string]bool
+}
+
+// newReplayScope 根据执行器加载的规则包确定对比范围。规则集合包含额外的规则文件时，
+// 无法从清单得知这些规则输出哪些结论，返回 nil，对比记录中的全部结论
+func newReplayScope(executors ...*RuleExecutor) *replayScope {
+	scope := &replayScope{packs: make(map[string]bool), findings: make(map[string]bool)}
+	for _, executor := range executors {
+		if len(executor.RuleSet().Files) > 0 {
+			return nil
+		}
+		for _, manifest := range executor.RulePacks() {
+			scope.packs[manifest.Name] = true
+			for _, findingType := range manifest.Findings {
+				scope.findings[findingType] = true
+			}
+		}
+	}
+	return scope
+}
+
+// covers 记录中的结论是否参与对比：记录中输出该结论的规则包被新版本加载（新版本删除或改名了对应的规则时报告为 removed），
+// 或者新版本加载的规则包声明了该结论类型。记录中没有来源规则包的结论（来自额外的规则文件）总是参与对比
+func (scope *replayScope) covers(record *IncidentRecord, finding Finding) bool {
+	if scope == nil {
+		return true
+	}
+	pack, ok := record.FindingPacks[finding.Type]
+	return !ok || scope.packs[pack] || scope.findings[finding.Type]
+}
+
+// Replay 使用执行器的规则版本重新评估历史记录中的输入快照，并与记录中的结论对比，结果顺序与输入一致。
+// nodeExecutor 为 nil 时，使用记录中保存的节点级规则集合执行节点级规则（记录生成时没有执行节点级规则则跳过）。
+// 只对比新版本加载的规则包范围内的结论（见 replayScope），记录中其他规则包的结论放入 Ignored，不会报告为 removed
+func (executor *TiDBRuleExecutor) Replay(records []*IncidentRecord, nodeExecutor *TiKVNodeRuleExecutor, workers int) []ReplayResult {
+	monitors := make([]*TiDBMonitor, len(records))
+	for i, record := range records {
+		monitors[i] = record.Input.BuildMonitor()
+	}
+
+	nodeExecutors := make(map[string]*TiKVNodeRuleExecutor) // 记录中的节点级规则集合 -> 执行器
+	results := make([]ReplayResult, len(records))
+	for i, evaluated := range executor.EvaluateBatch(monitors, workers) {
+		record := records[i]
+		result := ReplayResult{Record: record, FiredRules: evaluated.FiredRules, Err: evaluated.Err}
+		recordNodeExecutor := nodeExecutor
+		if result.Err == nil && recordNodeExecutor == nil && record.NodeRuleSet != nil {
+			recordNodeExecutor, result.Err = executor.recordedNodeExecutor(record.NodeRuleSet, nodeExecutors)
+		}
+		if result.Err == nil && recordNodeExecutor != nil {
+			if _, err := recordNodeExecutor.EvaluateNodes(evaluated.Monitor); err != nil {
+				result.Err = err
+			} else {
+				result.FiredRules = append(result.FiredRules, evaluated.Monitor.NodeFiredRules()...)
+			}
+		}
+		if result.Err != nil {
+			result.Err = fmt.Errorf("回放集群 %s 在 %s 的记录失败: %v", record.ClusterID, record.Time.Format("2006-01-02 15:04:05"), result.Err)
+			results[i] = result
+			continue
+		}
+
+		scope := newReplayScope(executor.RuleExecutor)
+		if recordNodeExecutor != nil {
+			scope = newReplayScope(executor.RuleExecutor, recordNodeExecutor.RuleExecutor)
+		}
+		var baseline []Finding
+		for _, finding := range record.Findings {
+			if scope.covers(record, finding) {
+				baseline = append(baseline, finding)
+			} else {
+				result.Ignored = append(result.Ignored, finding)
+			}
+		}
+		result.Findings = evaluated.Monitor.Findings()
+		result.Diffs = DiffFindings(baseline, result.Findings)
+		results[i] = result
+	}
+	return results
+}
+
+// recordedNodeExecutor 使用记录中保存的节点级规则集合创建执行器，相同的规则集合只创建一次
+func (executor *TiDBRuleExecutor) recordedNodeExecutor(ruleSet *RuleSet, cache map[string]*TiKVNodeRuleExecutor) (*TiKVNodeRuleExecutor, error) {
+	key := ruleSet.String()
+	if nodeExecutor, ok := cache[key]; ok {
+		return nodeExecutor, nil
+	}
+	nodeExecutor, err := NewTiKVNodeRuleExecutorWithRuleSet(ruleSet, executor.RuleName()+"Node", executor.RuleVersion())
+	if err != nil {
+		return nil, fmt.Errorf("初始化记录中的节点级规则执行器失败: %v", err)
+	}
+	cache[key] = nodeExecutor
+	return nodeExecutor, nil
+}
+
+// SummarizeReplay 统计回放结果中的差异
+func SummarizeReplay(results []ReplayResult) ReplaySummary {
+	summary := ReplaySummary{Records: len(results)}
+	for _, result := range results {
+		if result.Err != nil {
+			summary.Failed++
+			continue
+		}
+		summary.Ignored += len(result.Ignored)
+		if result.HasDiff() {
+			summary.Changed++
+		}
+		for _, diff := range result.Diffs {
+			switch diff.Kind {
+			case FindingAdded:
+				summary.Added++
+			case FindingRemoved:
+				summary.Removed++
+			case FindingChanged:
+				summary.ChangedFindings++
+			}
+		}
+	}
+	return summary
+}
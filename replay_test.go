@@ -0,0 +1,172 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	store, err := OpenIncidentStore(filepath.Join(t.TempDir(), "incidents.db"))
+	if err != nil {
+		t.Fatalf("打开事件历史失败: %v", err)
+	}
+	defer store.Close()
+
+	// 三个时间点：tikv-3 为 90（明显的写热点），没有热点，tikv-2 为 45（约 1.59 倍的弱热点）
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i, cpu := range []map[string]float64{
+		{"tikv-3": 90},
+		{},
+		{"tikv-2": 45},
+	} {
+		input := ScenarioInput{CheckWriteHotspot: true}
+		for _, id := range []string{"tikv-1", "tikv-2", "tikv-3"} {
+			node := &TiKVNode{NodeID: id, RaftstoreCPU: 20}
+			if value, ok := cpu[id]; ok {
+				node.RaftstoreCPU = value
+			}
+			input.Nodes = append(input.Nodes, node)
+		}
+		monitor := input.BuildMonitor()
+		firedRules, err := executor.ExecuteWithTrace(monitor)
+		if err != nil {
+			t.Fatalf("执行规则失败: %v", err)
+		}
+		if err := store.Record(executor.NewIncidentRecord("prod", start.Add(time.Duration(i)*time.Minute), monitor, firedRules, nil)); err != nil {
+			t.Fatalf("写入事件历史失败: %v", err)
+		}
+	}
+
+	// 新版本把写热点阈值从平均值的 1.5 倍提高到 1.6 倍，并按平均值的 2 倍计算比例
+	data, err := os.ReadFile("tidb.grl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2 := strings.Replace(string(data), "TiDBMonitor.AvgRaftstoreCPU * 1.5", "TiDBMonitor.AvgRaftstoreCPU * 1.6", 1)
+	v2 = strings.Replace(v2, "TiDBMonitor.MaxRaftstoreCPU / TiDBMonitor.AvgRaftstoreCPU;", "TiDBMonitor.MaxRaftstoreCPU / TiDBMonitor.AvgRaftstoreCPU / 2;", 1)
+	if v2 == string(data) {
+		t.Fatalf("tidb.grl 中没有找到写热点阈值")
+	}
+	ruleFile := filepath.Join(t.TempDir(), "tidb-v2.grl")
+	if err := os.WriteFile(ruleFile, []byte(v2), 0644); err != nil {
+		t.Fatal(err)
+	}
+	candidate, err := NewTiDBRuleExecutorWithFiles([]string{ruleFile, "tidb_shard_row_id_bits.table.yaml"}, "TiDBHotspot", "2.0.0")
+	if err != nil {
+		t.Fatalf("初始化新版本规则执行器失败: %v", err)
+	}
+
+	records, err := store.Query(IncidentQuery{ClusterID: "prod", From: start, To: start.Add(2 * time.Minute)})
+	if err != nil || len(records) != 3 {
+		t.Fatalf("查询记录失败: %v %d", err, len(records))
+	}
+	results := candidate.Replay(records, nil, 2)
+	if len(results) != 3 {
+		t.Fatalf("回放结果数不符: %d", len(results))
+	}
+	if diffs := results[0].Diffs; len(diffs) != 1 || diffs[0].Kind != FindingChanged || diffs[0].Key != "write_hotspot@tikv-3" ||
+		math.Abs(diffs[0].Candidate.Value*2-diffs[0].Baseline.Value) > findingValueTolerance {
+		t.Errorf("tikv-3 的热点比例应减半: %v", diffs)
+	}
+	if results[1].HasDiff() {
+		t.Errorf("没有热点的记录不应有差异: %v", results[1].Diffs)
+	}
+	if diffs := results[2].Diffs; len(diffs) != 1 || diffs[0].Kind != FindingRemoved || diffs[0].Key != "write_hotspot@tikv-2" {
+		t.Errorf("tikv-2 的弱热点在新阈值下应消失: %v", diffs)
+	}
+	if summary := SummarizeReplay(results); summary != (ReplaySummary{Records: 3, Changed: 2, Removed: 1, ChangedFindings: 1}) {
+		t.Errorf("回放汇总不符: %+v", summary)
+	}
+
+	// 同一版本回放没有差异；记录生成时没有执行节点级规则，传入节点级执行器后节点级结论为新增
+	if summary := SummarizeReplay(executor.Replay(records, nil, 0)); summary.Changed != 0 {
+		t.Errorf("同一版本回放不应有差异: %+v", summary)
+	}
+	nodeExecutor, err := NewTiKVNodeRuleExecutorWithPack("tikv-node", nil, "TiKVNode", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化节点级规则执行器失败: %v", err)
+	}
+	results = executor.Replay(records[:1], nodeExecutor, 0)
+	if diffs := results[0].Diffs; len(diffs) != 1 || diffs[0].Kind != FindingAdded || diffs[0].Key != "node_raftstore_outlier@tikv-3" {
+		t.Errorf("节点级规则的结论应为新增: %v", diffs)
+	}
+
+	// 只对比回放时加载的规则包的结论：只加载 tidb-server 时写热点结论不参与对比
+	serverOnly, err := NewTiDBRuleExecutorWithPack("tidb-server", nil, "TiDBHotspot", "2.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	results = serverOnly.Replay(records, nil, 0)
+	if summary := SummarizeReplay(results); summary != (ReplaySummary{Records: 3, Ignored: 2}) {
+		t.Errorf("没有加载的规则包的结论应忽略: %+v", summary)
+	}
+	if ignored := results[0].Ignored; len(ignored) != 1 || ignored[0].Key() != "write_hotspot@tikv-3" {
+		t.Errorf("忽略的结论不符: %v", ignored)
+	}
+
+	// 新版本的 tidb-hotspot 删除了写热点规则：规则包仍然加载，记录中的写热点结论报告为消失
+	overrideDir := t.TempDir()
+	withoutWriteRule := string(data[strings.Index(string(data), "}\n")+2:])
+	if err := os.WriteFile(filepath.Join(overrideDir, "tidb.grl"), []byte(withoutWriteRule), 0644); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := NewTiDBRuleExecutorWithRuleSet(&RuleSet{Packs: []string{DefaultRulePack}, OverrideDir: overrideDir}, "TiDBHotspot", "3.0.0")
+	if err != nil {
+		t.Fatalf("初始化新版本规则执行器失败: %v", err)
+	}
+	if summary := SummarizeReplay(removed.Replay(records, nil, 0)); summary != (ReplaySummary{Records: 3, Changed: 2, Removed: 2}) {
+		t.Errorf("删除规则后记录中的结论应为消失: %+v", summary)
+	}
+}
+
+func TestReplayRecordedNodeRuleSet(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	nodeExecutor, err := NewTiKVNodeRuleExecutorWithPack("tikv-node", nil, "TiKVNode", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化节点级规则执行器失败: %v", err)
+	}
+
+	monitor := historyMonitor("tikv-3", "")
+	firedRules, err := executor.ExecuteWithTrace(monitor)
+	if err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if _, err := nodeExecutor.EvaluateNodes(monitor); err != nil {
+		t.Fatalf("执行节点级规则失败: %v", err)
+	}
+	record := executor.NewIncidentRecord("prod", time.Now(), monitor, append(firedRules, monitor.NodeFiredRules()...), nodeExecutor)
+	if record.RuleSet == nil || strings.Join(record.RuleSet.Packs, ",") != DefaultRulePack ||
+		record.NodeRuleSet == nil || strings.Join(record.NodeRuleSet.Packs, ",") != "tikv-node" {
+		t.Fatalf("记录中的规则集合不符: %+v %+v", record.RuleSet, record.NodeRuleSet)
+	}
+	if record.FindingPacks[FindingWriteHotspot] != DefaultRulePack || record.FindingPacks[FindingNodeRaftstoreOutlier] != "tikv-node" {
+		t.Errorf("记录中结论类型的来源规则包不符: %v", record.FindingPacks)
+	}
+
+	// 不传入节点级执行器时使用记录中的节点级规则集合，节点级结论不会显示为消失
+	results := executor.Replay([]*IncidentRecord{record}, nil, 0)
+	if results[0].Err != nil || results[0].HasDiff() || len(results[0].Ignored) != 0 ||
+		!containsString(results[0].FiredRules, "DetectRaftstoreOutlierNode") {
+		t.Errorf("使用记录中的节点级规则集合回放不应有差异: %+v", results[0])
+	}
+
+	// 记录中的规则文件保存为绝对路径
+	fileExecutor, err := NewTiDBRuleExecutorWithFiles([]string{"tidb.grl"}, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	if recorded := fileExecutor.NewIncidentRecord("prod", time.Now(), monitor, nil, nil).RuleSet; len(recorded.Files) != 1 || !filepath.IsAbs(recorded.Files[0]) {
+		t.Errorf("规则文件应保存为绝对路径: %+v", recorded)
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 报告格式
+const (
+	ReportFormatMarkdown = "markdown"
+	ReportFormatHTML     = "html"
+)
+
+// ReportSummaryItem 集群概览中的一项
+type ReportSummaryItem struct {
+	Label string
+	Value string
+}
+
+// ReportNode 节点 CPU 表中的一行
+type ReportNode struct {
+	NodeID         string
+	Zone           string
+	RaftstoreCPU   float64
+	CoprocessorCPU float64
+	WriteHot       bool // 写热点节点
+	ReadHot        bool // 读热点节点
+}
+
+// Hot 节点是否需要在报告中高亮
+func (node ReportNode) Hot() bool {
+	return node.WriteHot || node.ReadHot
+}
+
+// ReportRule 触发的规则
+type ReportRule struct {
+	Name        string
+	Description string
+}
+
+// ReportFinding 诊断结论及其证据、建议和修复 SQL
+type ReportFinding struct {
+	Finding
+	Title          string // 本地化的结论类型名称
+	Evidence       string
+	Recommendation string
+	SQL            []string
+}
+
+// DiagnosticReport 单次评估的诊断报告，可渲染为 Markdown 或自包含的 HTML
+type DiagnosticReport struct {
+	Locale      string // 报告语言，见 T
+	Title       string
+	GeneratedAt time.Time
+	RuleName    string
+	RuleVersion string
+	Summary     []ReportSummaryItem
+	Nodes       []ReportNode
+	Rules       []ReportRule
+	Findings    []ReportFinding
+}
+
+// NewDiagnosticReport 根据规则执行后的监控数据生成报告，describe 返回规则描述（可以为 nil）。
+// 报告语言为 monitor.Locale，为空时使用进程级默认语言
+func NewDiagnosticReport(monitor *TiDBMonitor, firedRules []string, describe func(ruleName string) string) *DiagnosticReport {
+	report := &DiagnosticReport{
+		Locale:      reportLocale(monitor),
+		GeneratedAt: time.Now(),
+	}
+	report.Title = report.T("report.title")
+
+	report.Summary = []ReportSummaryItem{
+		{Label: report.T("report.summary.tikv_nodes"), Value: fmt.Sprintf("%d", len(monitor.TiKVNodes))},
+		{Label: report.T("report.summary.raftstore_cpu"), Value: fmt.Sprintf("%.2f%% / %.2f%%", monitor.MaxRaftstoreCPU, monitor.AvgRaftstoreCPU)},
+		{Label: report.T("report.summary.coprocessor_cpu"), Value: fmt.Sprintf("%.2f%% / %.2f%%", monitor.MaxCoprocessorCPU, monitor.AvgCoprocessorCPU)},
+	}
+	if monitor.ZoneCount > 0 {
+		report.Summary = append(report.Summary, ReportSummaryItem{Label: report.T("report.summary.zones"), Value: fmt.Sprintf("%d", monitor.ZoneCount)})
+	}
+	if monitor.TiDBServerCount > 0 {
+		report.Summary = append(report.Summary, ReportSummaryItem{Label: report.T("report.summary.tidb_servers"), Value: fmt.Sprintf("%d", monitor.TiDBServerCount)})
+	}
+
+	for _, node := range monitor.TiKVNodes {
+		report.Nodes = append(report.Nodes, ReportNode{
+			NodeID:         node.NodeID,
+			Zone:           node.Zone(),
+			RaftstoreCPU:   node.RaftstoreCPU,
+			CoprocessorCPU: node.CoprocessorCPU,
+			WriteHot:       monitor.WriteHotspotDetected && node.NodeID == monitor.WriteHotspotNode,
+			ReadHot:        monitor.ReadHotspotDetected && node.NodeID == monitor.ReadHotspotNode,
+		})
+	}
+
+	seen := make(map[string]bool, len(firedRules))
+	for _, ruleName := range firedRules {
+		if seen[ruleName] {
+			continue
+		}
+		seen[ruleName] = true
+		rule := ReportRule{Name: ruleName}
+		if describe != nil {
+			rule.Description = describe(ruleName)
+		}
+		report.Rules = append(report.Rules, rule)
+	}
+
+	for _, finding := range monitor.Findings() {
+		report.Findings = append(report.Findings, describeFinding(monitor, finding, report.Locale))
+	}
+	report.Summary = append(report.Summary,
+		ReportSummaryItem{Label: report.T("report.summary.fired_rules"), Value: fmt.Sprintf("%d", len(report.Rules))},
+		ReportSummaryItem{Label: report.T("report.summary.findings"), Value: fmt.Sprintf("%d", len(report.Findings))},
+	)
+	return report
+}
+
+// reportLocale 报告语言：monitor.Locale，为空时使用进程级默认语言
+func reportLocale(monitor *TiDBMonitor) string {
+	if monitor.Locale != "" {
+		return monitor.Locale
+	}
+	return CurrentLocale()
+}
+
+// T 按报告语言翻译消息，模板中通过 {{$.T "report.title"}} 调用
+func (report *DiagnosticReport) T(key string, args ...interface{}) string {
+	return Translate(report.Locale, key, args...)
+}
+
+// Diagnose 执行规则并生成诊断报告，监控数据需要已经调用过 CalculateStatistics
+func (executor *TiDBRuleExecutor) Diagnose(monitor *TiDBMonitor) (*DiagnosticReport, error) {
+	return executor.DiagnoseWithNodes(monitor, nil)
+}
+
+// DiagnoseWithNodes 执行集群级规则后再用 nodeExecutor 对每个 TiKV 节点执行节点级规则，
+// 节点级结论和触发的规则一起写入诊断报告，nodeExecutor 为空时与 Diagnose 相同
+func (executor *TiDBRuleExecutor) DiagnoseWithNodes(monitor *TiDBMonitor, nodeExecutor *TiKVNodeRuleExecutor) (*DiagnosticReport, error) {
+	firedRules, err := executor.ExecuteWithTrace(monitor)
+	if err != nil {
+		return nil, err
+	}
+	if nodeExecutor != nil {
+		if _, err := nodeExecutor.EvaluateNodes(monitor); err != nil {
+			return nil, err
+		}
+		firedRules = append(firedRules, monitor.NodeFiredRules()...)
+	}
+	locale := reportLocale(monitor)
+	report := NewDiagnosticReport(monitor, firedRules, func(ruleName string) string {
+		if description := executor.LocalizedRuleDescription(locale, ruleName); description != "" || nodeExecutor == nil {
+			return description
+		}
+		return nodeExecutor.LocalizedRuleDescription(locale, ruleName)
+	})
+	report.RuleName = executor.RuleName()
+	report.RuleVersion = executor.RuleVersion()
+	return report, nil
+}
+
+// reportTableName 修复 SQL 中使用的表名：优先使用写入最多的语句涉及的第一张表
+func reportTableName(monitor *TiDBMonitor) string {
+	for _, table := range strings.Split(monitor.TopWriteTables, ",") {
+		if table = strings.TrimSpace(table); table != "" {
+			return table
+		}
+	}
+	return "<table_name>"
+}
+
+// hotRegionsSQL 查询热点 Region 的 SQL
+func hotRegionsSQL(regionType string) string {
+	return fmt.Sprintf("SELECT DB_NAME, TABLE_NAME, INDEX_NAME, REGION_ID, FLOW_BYTES FROM INFORMATION_SCHEMA.TIDB_HOT_REGIONS WHERE TYPE = '%s' ORDER BY FLOW_BYTES DESC LIMIT 10;", regionType)
+}
+
+// describeFinding 按语言为结论补充证据、建议和修复 SQL
+func describeFinding(monitor *TiDBMonitor, finding Finding, locale string) ReportFinding {
+	t := func(key string, args ...interface{}) string {
+		return Translate(locale, key, args...)
+	}
+	item := ReportFinding{Finding: finding, Title: finding.Title(locale)}
+	switch finding.Type {
+	case FindingWriteHotspot:
+		item.Evidence = t("report.evidence.write_hotspot",
+			finding.Target, monitor.MaxRaftstoreCPU, monitor.AvgRaftstoreCPU, finding.Value)
+		item.Recommendation = t("report.recommendation.write_hotspot")
+		item.SQL = []string{hotRegionsSQL("write")}
+	case FindingReadHotspot:
+		item.Evidence = t("report.evidence.read_hotspot",
+			finding.Target, monitor.MaxCoprocessorCPU, monitor.AvgCoprocessorCPU, finding.Value)
+		for _, digest := range finding.TopDigests {
+			item.Evidence += t("report.evidence.top_sql", digest.Digest, digest.KeysOn(monitor.storeAddresses(finding.Target)...))
+		}
+		if len(finding.TopDigests) > 0 {
+			item.Evidence += t("report.evidence.top_sql_estimated")
+		}
+		item.Recommendation = t("report.recommendation.read_hotspot")
+		item.SQL = []string{hotRegionsSQL("read"), "SET GLOBAL tidb_replica_read = 'leader-and-follower';"}
+	case FindingShardRowIDBits:
+		item.Evidence = t("report.evidence.shard_row_id_bits", monitor.WriteHotspotRatio)
+		item.Recommendation = t("report.recommendation.shard_row_id_bits", monitor.ShardRowIDBits)
+		item.SQL = []string{fmt.Sprintf("ALTER TABLE %s SHARD_ROW_ID_BITS = %d;", reportTableName(monitor), monitor.ShardRowIDBits)}
+	case FindingZoneWriteImbalance:
+		item.Evidence = t("report.evidence.zone_write_imbalance",
+			finding.Target, monitor.HotWriteZoneAvgRaftstoreCPU, monitor.OtherZonesAvgRaftstoreCPU, finding.Value)
+		item.Recommendation = t("report.recommendation.zone_write_imbalance")
+		item.SQL = []string{"SHOW PLACEMENT;"}
+	case FindingZoneReadImbalance:
+		item.Evidence = t("report.evidence.zone_read_imbalance",
+			finding.Target, monitor.HotReadZoneAvgCoprocessorCPU, monitor.OtherZonesAvgCoprocessorCPU, finding.Value)
+		item.Recommendation = t("report.recommendation.zone_read_imbalance")
+		item.SQL = []string{"SHOW PLACEMENT;", "SET GLOBAL tidb_replica_read = 'closest-replicas';"}
+	case FindingIsolatedWriteHotspot:
+		item.Evidence = t("report.evidence.isolated_write_hotspot",
+			finding.Target, monitor.MaxRaftstoreCPU, monitor.WriteHotspotZone, monitor.WriteHotspotZonePeerRaftstoreCPU, finding.Value)
+		item.Recommendation = t("report.recommendation.isolated_write_hotspot")
+		item.SQL = []string{hotRegionsSQL("write"),
+			fmt.Sprintf("SPLIT TABLE %s BETWEEN (0) AND (9223372036854775807) REGIONS 16;", reportTableName(monitor))}
+	case FindingTiDBServerSkew:
+		item.Evidence = t("report.evidence.tidb_server_skew", finding.Target, finding.Value)
+		item.Recommendation = t("report.recommendation.tidb_server_skew")
+		item.SQL = []string{"SELECT INSTANCE, COUNT(*) AS CONNECTIONS FROM INFORMATION_SCHEMA.CLUSTER_PROCESSLIST GROUP BY INSTANCE;"}
+	case FindingSharedHostHotspot:
+		item.Evidence = t("report.evidence.shared_host_write_hotspot", finding.Target, finding.Value)
+		item.Recommendation = t("report.recommendation.shared_host_write_hotspot")
+		item.SQL = []string{"SELECT STORE_ID, ADDRESS, LABEL, LEADER_COUNT FROM INFORMATION_SCHEMA.TIKV_STORE_STATUS;"}
+	case FindingTiDBServerHighLatency:
+		item.Evidence = t("report.evidence.tidb_server_high_latency", finding.Target, finding.Value)
+		item.Recommendation = t("report.recommendation.tidb_server_high_latency")
+		item.SQL = []string{"SELECT INSTANCE, DIGEST, QUERY_TIME, LEFT(QUERY, 100) FROM INFORMATION_SCHEMA.CLUSTER_SLOW_QUERY ORDER BY QUERY_TIME DESC LIMIT 10;"}
+	case FindingTiDBServerMemoryPressure, FindingTiDBServerOOMRisk:
+		item.Evidence = t("report.evidence.tidb_server_memory", finding.Target, finding.Value)
+		item.Recommendation = t("report.recommendation.tidb_server_memory")
+		item.SQL = []string{"SELECT INSTANCE, ID, MEM, LEFT(INFO, 100) FROM INFORMATION_SCHEMA.CLUSTER_PROCESSLIST ORDER BY MEM DESC LIMIT 10;",
+			"SET GLOBAL tidb_server_memory_limit = '80%';"}
+	case FindingThreadPoolSaturation:
+		item.Evidence = t("report.evidence.thread_pool", finding.Target, finding.Value*100)
+		for _, pool := range monitor.ThreadPools() {
+			if pool.Node+"/"+pool.Pool == finding.Target {
+				item.Recommendation = t("report.recommendation.thread_pool", pool.ConfigKey, pool.PoolSize, pool.RecommendedPoolSize)
+				item.SQL = []string{fmt.Sprintf("SET CONFIG tikv `%s` = %d;", pool.ConfigKey, pool.RecommendedPoolSize)}
+			}
+		}
+	case FindingInsertBatching:
+		item.Evidence = t("report.evidence.insert_batching", monitor.TopWriteTables, finding.Value*100, monitor.TopWriteExecCount)
+		item.Recommendation = t("report.recommendation.insert_batching")
+		item.SQL = []string{fmt.Sprintf("SELECT DIGEST_TEXT, EXEC_COUNT, AVG_WRITE_KEYS FROM INFORMATION_SCHEMA.CLUSTER_STATEMENTS_SUMMARY WHERE DIGEST = '%s';", finding.Target)}
+	case FindingWriteKeyRedesign:
+		item.Evidence = t("report.evidence.write_key_redesign",
+			monitor.TopWriteTables, finding.Value*100, monitor.TopWriteAvgPrewriteRegions)
+		item.Recommendation = t("report.recommendation.write_key_redesign")
+		item.SQL = []string{fmt.Sprintf("ALTER TABLE %s SHARD_ROW_ID_BITS = 4;", reportTableName(monitor))}
+	case FindingNodeCoprocessorOutlier:
+		item.Evidence = t("report.evidence.node_outlier", finding.Target, "Coprocessor", finding.Value)
+		item.Recommendation = t("report.recommendation.node_outlier")
+		item.SQL = []string{hotRegionsSQL("read")}
+	case FindingNodeRaftstoreOutlier:
+		item.Evidence = t("report.evidence.node_outlier", finding.Target, "Raftstore", finding.Value)
+		item.Recommendation = t("report.recommendation.node_outlier")
+		item.SQL = []string{hotRegionsSQL("write")}
+	}
+	return item
+}
+
+// Write 按格式（markdown / html）渲染报告
+func (report *DiagnosticReport) Write(writer io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case ReportFormatMarkdown, "md":
+		return report.WriteMarkdown(writer)
+	case ReportFormatHTML:
+		return report.WriteHTML(writer)
+	default:
+		return fmt.Errorf("不支持的报告格式: %s", format)
+	}
+}
+
+// reportFuncs 模板中使用的函数
+var reportFuncs = map[string]interface{}{
+	"time": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"pct":  func(value float64) string { return fmt.Sprintf("%.2f%%", value) },
+}
+
+// WriteMarkdown 渲染 Markdown 报告
+func (report *DiagnosticReport) WriteMarkdown(writer io.Writer) error {
+	return markdownReportTemplate.Execute(writer, report)
+}
+
+// WriteHTML 渲染自包含的 HTML 报告（样式内联，不依赖外部资源）
+func (report *DiagnosticReport) WriteHTML(writer io.Writer) error {
+	return htmlReportTemplate.Execute(writer, report)
+}
+
+var markdownReportTemplate = template.Must(template.New("markdown").Funcs(reportFuncs).Parse(`# {{.Title}}
+
+{{$.T "report.generated_at"}}: {{time .GeneratedAt}}{{if .RuleName}}
+{{$.T "report.rules"}}: {{.RuleName}}@{{.RuleVersion}}{{end}}
+
+## {{$.T "report.summary"}}
+
+| {{$.T "report.metric"}} | {{$.T "report.value"}} |
+|------|----|
+{{range .Summary}}| {{.Label}} | {{.Value}} |
+{{end}}
+## {{$.T "report.nodes"}}
+
+| {{$.T "report.node"}} | Zone | Raftstore CPU | Coprocessor CPU | {{$.T "report.status"}} |
+|------|------|---------------|-----------------|------|
+{{range .Nodes}}| {{if .Hot}}**{{.NodeID}}**{{else}}{{.NodeID}}{{end}} | {{.Zone}} | {{if .WriteHot}}**{{pct .RaftstoreCPU}}**{{else}}{{pct .RaftstoreCPU}}{{end}} | {{if .ReadHot}}**{{pct .CoprocessorCPU}}**{{else}}{{pct .CoprocessorCPU}}{{end}} | {{if .WriteHot}}🔥 {{$.T "report.write_hot"}}{{end}}{{if and .WriteHot .ReadHot}} / {{end}}{{if .ReadHot}}🔥 {{$.T "report.read_hot"}}{{end}} |
+{{end}}
+## {{$.T "report.fired_rules"}}
+{{if .Rules}}
+{{range .Rules}}- **{{.Name}}**{{if .Description}}: {{.Description}}{{end}}
+{{end}}{{else}}
+{{$.T "report.none"}}
+{{end}}
+## {{$.T "report.findings"}}
+{{if .Findings}}{{range .Findings}}
+### {{.Key}}{{if .Title}}: {{.Title}}{{end}}
+
+- {{$.T "report.evidence"}}: {{.Evidence}}
+{{if .Recommendation}}- {{$.T "report.recommendation"}}: {{.Recommendation}}
+{{end}}{{if .SQL}}
+` + "```sql" + `
+{{range .SQL}}{{.}}
+{{end}}` + "```" + `
+{{end}}{{end}}{{else}}
+{{$.T "report.no_findings"}}
+{{end}}`))
+
+var htmlReportTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+th { background: #f3f3f3; }
+tr.hot td { background: #fde2e1; }
+td.hot { color: #c0392b; font-weight: bold; }
+.finding { border-left: 4px solid #c0392b; padding: 0.2em 1em; margin: 1em 0; background: #fafafa; }
+pre { background: #272822; color: #f8f8f2; padding: 0.8em; overflow-x: auto; }
+.meta { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{$.T "report.generated_at"}}: {{time .GeneratedAt}}{{if .RuleName}}, {{$.T "report.rules"}}: {{.RuleName}}@{{.RuleVersion}}{{end}}</p>
+
+<h2>{{$.T "report.summary"}}</h2>
+<table>
+<tr><th>{{$.T "report.metric"}}</th><th>{{$.T "report.value"}}</th></tr>
+{{range .Summary}}<tr><td>{{.Label}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+
+<h2>{{$.T "report.nodes"}}</h2>
+<table>
+<tr><th>{{$.T "report.node"}}</th><th>Zone</th><th>Raftstore CPU</th><th>Coprocessor CPU</th><th>{{$.T "report.status"}}</th></tr>
+{{range .Nodes}}<tr{{if .Hot}} class="hot"{{end}}><td>{{.NodeID}}</td><td>{{.Zone}}</td><td{{if .WriteHot}} class="hot"{{end}}>{{pct .RaftstoreCPU}}</td><td{{if .ReadHot}} class="hot"{{end}}>{{pct .CoprocessorCPU}}</td><td>{{if .WriteHot}}{{$.T "report.write_hot"}}{{end}}{{if and .WriteHot .ReadHot}} / {{end}}{{if .ReadHot}}{{$.T "report.read_hot"}}{{end}}</td></tr>
+{{end}}</table>
+
+<h2>{{$.T "report.fired_rules"}}</h2>
+{{if .Rules}}<ul>
+{{range .Rules}}<li><strong>{{.Name}}</strong>{{if .Description}}: {{.Description}}{{end}}</li>
+{{end}}</ul>{{else}}<p>{{$.T "report.none"}}</p>{{end}}
+
+<h2>{{$.T "report.findings"}}</h2>
+{{if .Findings}}{{range .Findings}}<div class="finding">
+<h3>{{.Key}}{{if .Title}}: {{.Title}}{{end}}</h3>
+<p>{{$.T "report.evidence"}}: {{.Evidence}}</p>
+{{if .Recommendation}}<p>{{$.T "report.recommendation"}}: {{.Recommendation}}</p>{{end}}
+{{if .SQL}}<pre>{{range .SQL}}{{.}}
+{{end}}</pre>{{end}}
+</div>
+{{end}}{{else}}<p>{{$.T "report.no_findings"}}</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticReport(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	monitor := &TiDBMonitor{
+		CheckWriteHotspot:          true,
+		CheckReadHotspot:           true,
+		IsNonClusteredIndexHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 25.3, CoprocessorCPU: 22.1},
+			{NodeID: "tikv-2", RaftstoreCPU: 28.7, CoprocessorCPU: 24.5},
+			{NodeID: "tikv-3", RaftstoreCPU: 95.8, CoprocessorCPU: 23.2},
+			{NodeID: "tikv-<4>", RaftstoreCPU: 26.2, CoprocessorCPU: 25.1},
+		},
+	}
+	monitor.CalculateStatistics()
+
+	report, err := executor.Diagnose(monitor)
+	if err != nil {
+		t.Fatalf("生成报告失败: %v", err)
+	}
+	if report.RuleName != "TiDBHotspot" || len(report.Nodes) != 4 || !report.Nodes[2].WriteHot || report.Nodes[0].Hot() {
+		t.Errorf("报告节点信息不符: %+v", report.Nodes)
+	}
+	if len(report.Findings) != 2 || report.Findings[1].Type != FindingShardRowIDBits ||
+		report.Findings[1].SQL[0] != "ALTER TABLE <table_name> SHARD_ROW_ID_BITS = 10;" {
+		t.Errorf("报告结论不符: %+v", report.Findings)
+	}
+	for _, rule := range report.Rules {
+		if rule.Description == "" {
+			t.Errorf("规则 %s 缺少描述", rule.Name)
+		}
+	}
+
+	var markdown bytes.Buffer
+	if err := report.Write(&markdown, ReportFormatMarkdown); err != nil {
+		t.Fatalf("渲染 Markdown 失败: %v", err)
+	}
+	for _, want := range []string{"# TiDB 集群诊断报告", "| **tikv-3** |  | **95.80%** |", "### write_hotspot@tikv-3", "```sql", "DetectWriteHotspot"} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Errorf("Markdown 报告缺少 %q:\n%s", want, markdown.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := report.Write(&html, ReportFormatHTML); err != nil {
+		t.Fatalf("渲染 HTML 失败: %v", err)
+	}
+	for _, want := range []string{"<!DOCTYPE html>", `<tr class="hot"><td>tikv-3</td>`, "tikv-&lt;4&gt;", "&lt;table_name&gt;", "<style>"} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("HTML 报告缺少 %q", want)
+		}
+	}
+
+	if err := report.Write(&html, "pdf"); err == nil {
+		t.Errorf("不支持的格式应返回错误")
+	}
+}
+
+func TestDescribeReadHotspotEstimatedKeys(t *testing.T) {
+	monitor := &TiDBMonitor{
+		TiKVNodes: []*TiKVNode{{NodeID: "tikv-2", Address: "10.0.1.2:20160"}},
+	}
+	finding := Finding{Type: FindingReadHotspot, Target: "tikv-2", Value: 3.2, TopDigests: []*SlowQueryDigest{
+		{Digest: "d1", ProcessKeys: 1000000, StoreKeys: map[string]int64{"10.0.1.2:20160": 250000, "10.0.1.3:20160": 750000}},
+	}}
+	item := describeFinding(monitor, finding, LocaleZhCN)
+	for _, want := range []string{"Top SQL d1 在该节点上估算处理 250000 个 key", "Process_keys / Num_cop_tasks"} {
+		if !strings.Contains(item.Evidence, want) {
+			t.Errorf("读热点证据缺少 %q: %s", want, item.Evidence)
+		}
+	}
+}
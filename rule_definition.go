@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+	"github.com/hyperjumptech/grule-rule-engine/builder"
+	"github.com/hyperjumptech/grule-rule-engine/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// ruleDefinitionSuffixes 结构化规则定义文件的后缀，加载时转换为 GRL
+var ruleDefinitionSuffixes = []string{".rules.yaml", ".rules.yml", ".rules.json"}
+
+// 规则定义的导出格式
+const (
+	RuleFormatGRL  = "grl"
+	RuleFormatYAML = "yaml"
+	RuleFormatJSON = "json"
+)
+
+// RuleDefinitionFile 结构化规则定义文件，不熟悉 GRL 的同事可以用 YAML / JSON 编写规则：
+//
+//	rules:
+//	  - name: DetectWriteHotspot
+//	    description: 检测写热点
+//	    salience: 10
+//	    when:
+//	      - TiDBMonitor.CheckWriteHotspot == true
+//	      - TiDBMonitor.MaxRaftstoreCPU > TiDBMonitor.AvgRaftstoreCPU * 1.5
+//	    then:
+//	      - TiDBMonitor.WriteHotspotDetected = true
+type RuleDefinitionFile struct {
+	Rules []*RuleDefinition `json:"rules" yaml:"rules"`
+}
+
+// RuleDefinition 单条规则：when 中的条件以 && 连接，then 中的动作依次执行，
+// retract 为空或 true 时在动作最后撤回规则自身（与内置规则的写法一致）
+type RuleDefinition struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Salience    int      `json:"salience" yaml:"salience"`
+	When        []string `json:"when" yaml:"when"`
+	Then        []string `json:"then" yaml:"then"`
+	Retract     *bool    `json:"retract,omitempty" yaml:"retract,omitempty"`
+}
+
+// isRuleDefinitionFile 文件是否为结构化规则定义
+func isRuleDefinitionFile(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range ruleDefinitionSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeneratedRuleFile 规则文件是否需要先转换为 GRL（决策表或结构化规则定义）
+func isGeneratedRuleFile(name string) bool {
+	return isDecisionTableFile(name) || isRuleDefinitionFile(name)
+}
+
+// generatedRuleResource 将决策表或结构化规则定义转换为可以加载到知识库的 GRL 资源
+func generatedRuleResource(fsys fs.FS, name string) (pkg.Resource, error) {
+	if isDecisionTableFile(name) {
+		return decisionTableResource(fsys, name)
+	}
+	rules, err := LoadRuleDefinitions(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	grl, err := RuleDefinitionsGRL(rules)
+	if err != nil {
+		return nil, fmt.Errorf("规则定义 %s: %v", name, err)
+	}
+	return pkg.NewBytesResource([]byte(grl)), nil
+}
+
+// LoadRuleDefinitionFile 加载磁盘上的结构化规则定义
+func LoadRuleDefinitionFile(path string) ([]*RuleDefinition, error) {
+	return LoadRuleDefinitions(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
+
+// LoadRuleDefinitions 从 fsys 加载结构化规则定义（YAML / JSON）
+func LoadRuleDefinitions(fsys fs.FS, name string) ([]*RuleDefinition, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("读取规则定义 %s 失败: %v", name, err)
+	}
+	var file RuleDefinitionFile
+	if strings.HasSuffix(strings.ToLower(name), ".json") {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析规则定义 %s 失败: %v", name, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("规则定义 %s 中没有规则", name)
+	}
+	return file.Rules, nil
+}
+
+// retracts 触发后是否撤回规则自身
+func (rule *RuleDefinition) retracts() bool {
+	return rule.Retract == nil || *rule.Retract
+}
+
+// validate 校验规则定义
+func (rule *RuleDefinition) validate() error {
+	if !grlIdentifier.MatchString(rule.Name) {
+		return fmt.Errorf("规则名 %q 不合法", rule.Name)
+	}
+	if len(rule.When) == 0 {
+		return fmt.Errorf("规则 %s 没有任何条件", rule.Name)
+	}
+	for _, condition := range rule.When {
+		if strings.TrimSpace(condition) == "" {
+			return fmt.Errorf("规则 %s 有空的条件", rule.Name)
+		}
+	}
+	if len(rule.Then) == 0 && !rule.retracts() {
+		return fmt.Errorf("规则 %s 没有任何动作", rule.Name)
+	}
+	return nil
+}
+
+// GRL 规则的 GRL 文本
+func (rule *RuleDefinition) GRL() (string, error) {
+	if err := rule.validate(); err != nil {
+		return "", err
+	}
+
+	var grl strings.Builder
+	fmt.Fprintf(&grl, "rule %s %s salience %d {\n    when\n", rule.Name, strconv.Quote(rule.Description), rule.Salience)
+	for i, condition := range rule.When {
+		condition = strings.TrimSpace(condition)
+		// 条件之间以 && 连接，顶层包含 || 的条件加括号保持原有语义
+		if len(rule.When) > 1 && len(splitTopLevel(condition, "||")) > 1 {
+			condition = "(" + condition + ")"
+		}
+		separator := " &&"
+		if i == len(rule.When)-1 {
+			separator = ""
+		}
+		fmt.Fprintf(&grl, "        %s%s\n", condition, separator)
+	}
+	grl.WriteString("    then\n")
+	actions := rule.Then
+	if rule.retracts() {
+		actions = append(append([]string{}, actions...), fmt.Sprintf("Retract(%q)", rule.Name))
+	}
+	for _, action := range actions {
+		fmt.Fprintf(&grl, "        %s;\n", strings.TrimSuffix(strings.TrimSpace(action), ";"))
+	}
+	grl.WriteString("}\n")
+	return grl.String(), nil
+}
+
+// RuleDefinitionsGRL 将多条规则转换为 GRL，规则之间以空行分隔，规则名不允许重复
+func RuleDefinitionsGRL(rules []*RuleDefinition) (string, error) {
+	seen := make(map[string]bool, len(rules))
+	parts := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if seen[rule.Name] {
+			return "", fmt.Errorf("规则 %s 重复", rule.Name)
+		}
+		seen[rule.Name] = true
+		grl, err := rule.GRL()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, grl)
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// ruleHeader GRL 中规则声明的开头
+var ruleHeader = regexp.MustCompile(`\brule\s+([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ExportRuleDefinitions 将 GRL 导出为结构化规则定义（按规则在文件中的顺序），
+// 条件和动作保留原文的写法；顶层 && 连接的条件拆成多个条件，最后一个动作为撤回自身时记为 retract
+func ExportRuleDefinitions(grl string) ([]*RuleDefinition, error) {
+	// 先用 Grule 解析，保证导出的是合法规则，名称、描述和优先级以解析结果为准
+	library := ast.NewKnowledgeLibrary()
+	if err := builder.NewRuleBuilder(library).BuildRuleFromResource("Export", "0.0.0", pkg.NewBytesResource([]byte(grl))); err != nil {
+		return nil, fmt.Errorf("解析 GRL 失败: %v", err)
+	}
+	entries := library.NewKnowledgeBaseInstance("Export", "0.0.0").RuleEntries
+
+	source := stripGRLComments(grl)
+	var rules []*RuleDefinition
+	for offset := 0; offset < len(source); {
+		match := ruleHeader.FindStringSubmatchIndex(source[offset:])
+		if match == nil {
+			break
+		}
+		name := source[offset+match[2] : offset+match[3]]
+		open := indexTopLevel(source, offset+match[1], "{")
+		closing := -1
+		if open >= 0 {
+			closing = indexTopLevel(source, open+1, "}")
+		}
+		if closing < 0 {
+			return nil, fmt.Errorf("规则 %s 的规则体不完整", name)
+		}
+		entry, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("无法定位规则 %s", name)
+		}
+		rule, err := exportRuleBody(entry, source[open+1:closing])
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+		offset = closing + 1
+	}
+	if len(rules) != len(entries) {
+		return nil, fmt.Errorf("导出了 %d 条规则，但 GRL 中有 %d 条", len(rules), len(entries))
+	}
+	return rules, nil
+}
+
+// selfRetract 撤回规则自身的动作
+var selfRetract = regexp.MustCompile(`^Retract\(\s*"([^"]*)"\s*\)$`)
+
+// whenKeyword / thenKeyword 规则体中的关键字
+var (
+	whenKeyword = regexp.MustCompile(`^\s*when\b`)
+	thenKeyword = regexp.MustCompile(`\bthen\b`)
+)
+
+// exportRuleBody 从规则体（{ 与 } 之间的文本）中拆出条件和动作
+func exportRuleBody(entry *ast.RuleEntry, body string) (*RuleDefinition, error) {
+	rule := &RuleDefinition{Name: entry.RuleName, Description: entry.RuleDescription, Salience: entry.Salience}
+
+	when := whenKeyword.FindStringIndex(body)
+	if when == nil {
+		return nil, fmt.Errorf("规则 %s 缺少 when", rule.Name)
+	}
+	body = body[when[1]:]
+	then := -1
+	for _, match := range thenKeyword.FindAllStringIndex(body, -1) {
+		if atTopLevel(body, match[0]) {
+			then = match[0]
+			break
+		}
+	}
+	if then < 0 {
+		return nil, fmt.Errorf("规则 %s 缺少 then", rule.Name)
+	}
+
+	condition := strings.TrimSpace(body[:then])
+	if len(splitTopLevel(condition, "||")) > 1 {
+		rule.When = []string{condition}
+	} else {
+		rule.When = splitTopLevel(condition, "&&")
+	}
+
+	rule.Then = splitTopLevel(body[then+len("then"):], ";")
+	retract := false
+	if last := len(rule.Then) - 1; last >= 0 {
+		if match := selfRetract.FindStringSubmatch(rule.Then[last]); match != nil && match[1] == rule.Name {
+			rule.Then = rule.Then[:last]
+			retract = true
+		}
+	}
+	if !retract {
+		rule.Retract = &retract
+	}
+	return rule, nil
+}
+
+// splitTopLevel 按不在括号和字符串中的分隔符拆分，去掉空白和空的部分
+func splitTopLevel(text, separator string) []string {
+	var parts []string
+	for {
+		index := indexTopLevel(text, 0, separator)
+		if index < 0 {
+			break
+		}
+		parts = append(parts, text[:index])
+		text = text[index+len(separator):]
+	}
+	parts = append(parts, text)
+
+	trimmed := parts[:0]
+	for _, part := range parts {
+		if part = collapseSpace(part); part != "" {
+			trimmed = append(trimmed, part)
+		}
+	}
+	return trimmed
+}
+
+// collapseSpace 将字符串字面量之外的连续空白（如跨行条件的换行和缩进）合并为一个空格，并去掉首尾空白
+func collapseSpace(text string) string {
+	var out strings.Builder
+	var quote byte
+	space := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if quote == 0 && (c == ' ' || c == '\t' || c == '\n' || c == '\r') {
+			space = true
+			continue
+		}
+		if space && out.Len() > 0 {
+			out.WriteByte(' ')
+		}
+		space = false
+		out.WriteByte(c)
+		if quote != 0 {
+			if c == '\\' && i+1 < len(text) {
+				i++
+				out.WriteByte(text[i])
+			} else if c == quote {
+				quote = 0
+			}
+		} else if c == '"' || c == '\'' {
+			quote = c
+		}
+	}
+	return out.String()
+}
+
+// indexTopLevel 从 start 开始查找不在括号和字符串中的 target，不存在时返回 -1
+func indexTopLevel(text string, start int, target string) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if depth == 0 && strings.HasPrefix(text[i:], target) {
+			return i
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		}
+	}
+	return -1
+}
+
+// atTopLevel 位置 index 是否不在括号和字符串中
+func atTopLevel(text string, index int) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < index && i < len(text); i++ {
+		c := text[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		}
+	}
+	return quote == 0 && depth == 0
+}
+
+// stripGRLComments 去掉字符串之外的 // 和 /* */ 注释，注释替换为空格以保持其他文本不变
+func stripGRLComments(grl string) string {
+	var out strings.Builder
+	var quote byte
+	for i := 0; i < len(grl); i++ {
+		c := grl[i]
+		switch {
+		case quote != 0:
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(grl) {
+				i++
+				out.WriteByte(grl[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			out.WriteByte(c)
+		case strings.HasPrefix(grl[i:], "//"):
+			for i < len(grl) && grl[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+		case strings.HasPrefix(grl[i:], "/*"):
+			end := strings.Index(grl[i+2:], "*/")
+			if end < 0 {
+				i = len(grl)
+			} else {
+				i += end + 3
+			}
+			out.WriteByte(' ')
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// MarshalRuleDefinitions 将规则定义输出为 grl / yaml / json
+func MarshalRuleDefinitions(rules []*RuleDefinition, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case RuleFormatGRL:
+		grl, err := RuleDefinitionsGRL(rules)
+		return []byte(grl), err
+	case RuleFormatYAML, "yml":
+		var buffer bytes.Buffer
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&RuleDefinitionFile{Rules: rules}); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), encoder.Close()
+	case RuleFormatJSON:
+		// 条件中的 > / < / && 不做 HTML 转义，保持可读
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(&RuleDefinitionFile{Rules: rules})
+		return buffer.Bytes(), err
+	default:
+		return nil, fmt.Errorf("不支持的规则格式: %s", format)
+	}
+}
+
+// LoadRulesAsDefinitions 加载任意规则文件（.grl、决策表或结构化规则定义）并转换为结构化规则定义，用于格式转换
+func LoadRulesAsDefinitions(path string) ([]*RuleDefinition, error) {
+	if isRuleDefinitionFile(path) {
+		return LoadRuleDefinitionFile(path)
+	}
+
+	var grl string
+	if isDecisionTableFile(path) {
+		table, err := LoadDecisionTableFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if grl, err = table.Compile(); err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取规则文件 %s 失败: %v", path, err)
+		}
+		grl = string(data)
+	}
+	rules, err := ExportRuleDefinitions(grl)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return rules, nil
+}
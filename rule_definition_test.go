@@ -0,0 +1,212 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+	"github.com/hyperjumptech/grule-rule-engine/builder"
+	"github.com/hyperjumptech/grule-rule-engine/pkg"
+)
+
+// buildRuleEntries 解析 GRL，返回规则名 -> 规则
+func buildRuleEntries(t *testing.T, grl string) map[string]*ast.RuleEntry {
+	t.Helper()
+	library := ast.NewKnowledgeLibrary()
+	if err := builder.NewRuleBuilder(library).BuildRuleFromResource("Test", "1.0.0", pkg.NewBytesResource([]byte(grl))); err != nil {
+		t.Fatalf("解析 GRL 失败: %v\n%s", err, grl)
+	}
+	return library.NewKnowledgeBaseInstance("Test", "1.0.0").RuleEntries
+}
+
+func TestRuleDefinitionRoundTrip(t *testing.T) {
+	// 所有内置 GRL 导出为 YAML / JSON 再转换回 GRL 后，规则与原文一致（Grule 的 GrlText 不含空白）
+	grlFiles, err := fs.Glob(builtinRuleFS, "*.grl")
+	if err != nil || len(grlFiles) == 0 {
+		t.Fatalf("查找内置规则文件失败: %v", err)
+	}
+	for _, file := range grlFiles {
+		data, err := fs.ReadFile(builtinRuleFS, file)
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", file, err)
+		}
+		rules, err := ExportRuleDefinitions(string(data))
+		if err != nil {
+			t.Fatalf("导出 %s 失败: %v", file, err)
+		}
+		original := buildRuleEntries(t, string(data))
+
+		for _, format := range []string{RuleFormatYAML, RuleFormatJSON} {
+			exported, err := MarshalRuleDefinitions(rules, format)
+			if err != nil {
+				t.Fatalf("%s: 输出 %s 失败: %v", file, format, err)
+			}
+			path := filepath.Join(t.TempDir(), "export.rules."+format)
+			if err := os.WriteFile(path, exported, 0644); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadRuleDefinitionFile(path)
+			if err != nil {
+				t.Fatalf("%s: 加载 %s 失败: %v", file, format, err)
+			}
+			grl, err := RuleDefinitionsGRL(loaded)
+			if err != nil {
+				t.Fatalf("%s: 转换为 GRL 失败: %v", file, err)
+			}
+
+			converted := buildRuleEntries(t, grl)
+			if len(converted) != len(original) {
+				t.Fatalf("%s: 规则数不符: %d != %d", file, len(converted), len(original))
+			}
+			for name, entry := range original {
+				got := converted[name]
+				if got == nil || got.RuleDescription != entry.RuleDescription || got.Salience != entry.Salience ||
+					got.WhenScope.GrlText != entry.WhenScope.GrlText || got.ThenScope.GrlText != entry.ThenScope.GrlText {
+					t.Errorf("%s (%s): 规则 %s 往返转换后不一致", file, format, name)
+				}
+			}
+		}
+	}
+
+	// 导出保持规则顺序和原文写法，撤回自身是默认行为（省略 retract），撤回其他规则保留为动作并记为 retract: false
+	data, _ := fs.ReadFile(builtinRuleFS, "rules.grl")
+	rules, _ := ExportRuleDefinitions(string(data))
+	if rules[0].Name != "SpeedUp" || rules[0].Retract != nil || len(rules[0].Then) != 1 {
+		t.Errorf("SpeedUp 导出不符: %+v", rules[0])
+	}
+	if rules[1].Name != "SpeedDown" || rules[1].When[1] != "TestCar.Speed > 0" {
+		t.Errorf("SpeedDown 导出不符: %+v", rules[1])
+	}
+
+	rules, err = ExportRuleDefinitions(`rule Accelerate "加速" salience 10 {
+    when
+        TestCar.SpeedUp == true
+    then
+        TestCar.Speed = TestCar.Speed + TestCar.SpeedIncrement;
+        Retract("Brake");
+}`)
+	if err != nil {
+		t.Fatalf("导出规则失败: %v", err)
+	}
+	if rules[0].Retract == nil || *rules[0].Retract || rules[0].Then[len(rules[0].Then)-1] != `Retract("Brake")` {
+		t.Errorf("撤回其他规则应保留为动作: %+v", rules[0])
+	}
+}
+
+func TestExportRuleDefinitionsSyntax(t *testing.T) {
+	grl := `// 注释中的 rule Fake "x" { when a then b }
+rule Mixed "包含 ; 和 && 的字符串" salience 3 {
+    when
+        (TiDBMonitor.CheckWriteHotspot == true || TiDBMonitor.CheckReadHotspot == true) &&
+        TiDBMonitor.WriteHotspotNode != "a && b;  c" /* 行内注释 */ &&
+        TiDBMonitor.MaxRaftstoreCPU >
+            TiDBMonitor.AvgRaftstoreCPU
+    then
+        TiDBMonitor.TopWriteTables = "x;  y";
+        Retract("Mixed");
+}
+
+rule Either "顶层 ||" {
+    when
+        TiDBMonitor.CheckWriteHotspot == true || TiDBMonitor.CheckReadHotspot == true
+    then
+        TiDBMonitor.TopWriteDigest = "then";
+}
+`
+	rules, err := ExportRuleDefinitions(grl)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("规则数不符: %d", len(rules))
+	}
+	mixed := rules[0]
+	wantWhen := []string{
+		"(TiDBMonitor.CheckWriteHotspot == true || TiDBMonitor.CheckReadHotspot == true)",
+		`TiDBMonitor.WriteHotspotNode != "a && b;  c"`,
+		"TiDBMonitor.MaxRaftstoreCPU > TiDBMonitor.AvgRaftstoreCPU",
+	}
+	if strings.Join(mixed.When, "\n") != strings.Join(wantWhen, "\n") || mixed.Salience != 3 || mixed.Retract != nil ||
+		strings.Join(mixed.Then, "|") != `TiDBMonitor.TopWriteTables = "x;  y"` {
+		t.Errorf("Mixed 导出不符: %+v", mixed)
+	}
+	either := rules[1]
+	if len(either.When) != 1 || either.Retract == nil || *either.Retract {
+		t.Errorf("顶层 || 的条件不应拆分: %+v", either)
+	}
+
+	// 多个条件中有顶层 || 时加括号
+	retract := false
+	grl, err = RuleDefinitionsGRL([]*RuleDefinition{{Name: "Or", When: []string{"A == 1 || B == 2", "C == 3"}, Then: []string{"D = 4;"}, Retract: &retract}})
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	if !strings.Contains(grl, "(A == 1 || B == 2) &&\n        C == 3\n    then\n        D = 4;\n}") {
+		t.Errorf("生成的 GRL 不符:\n%s", grl)
+	}
+
+	for name, rules := range map[string][]*RuleDefinition{
+		"规则名":  {{Name: "bad name", When: []string{"A"}}},
+		"没有条件": {{Name: "A"}},
+		"重复":   {{Name: "A", When: []string{"B"}}, {Name: "A", When: []string{"C"}}},
+		"没有动作": {{Name: "A", When: []string{"B"}, Retract: &retract}},
+	} {
+		if _, err := RuleDefinitionsGRL(rules); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestRuleDefinitionFileWithGRL(t *testing.T) {
+	dir := t.TempDir()
+	definition := filepath.Join(dir, "extra.rules.yaml")
+	rules := `rules:
+  - name: MarkHotTable
+    description: 写热点时标记热点表
+    salience: 1
+    when:
+      - TiDBMonitor.WriteHotspotDetected == true
+      - TiDBMonitor.TopWriteTables == ""
+    then:
+      - TiDBMonitor.TopWriteTables = "orders"
+      - TiDBMonitor.Message("msg.write_hotspot.detected", TiDBMonitor.WriteHotspotNode)
+`
+	if err := os.WriteFile(definition, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	executor, err := NewTiDBRuleExecutorWithFiles([]string{"tidb.grl", definition}, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	if executor.RuleDescription("MarkHotTable") != "写热点时标记热点表" {
+		t.Errorf("规则定义未加载")
+	}
+
+	monitor := &TiDBMonitor{
+		CheckWriteHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 20},
+			{NodeID: "tikv-2", RaftstoreCPU: 20},
+			{NodeID: "tikv-3", RaftstoreCPU: 90},
+		},
+	}
+	monitor.CalculateStatistics()
+	firedRules, err := executor.ExecuteWithTrace(monitor)
+	if err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if monitor.TopWriteTables != "orders" || strings.Count(strings.Join(firedRules, ","), "MarkHotTable") != 1 {
+		t.Errorf("规则定义执行结果不符: %v %q", firedRules, monitor.TopWriteTables)
+	}
+
+	broken := filepath.Join(dir, "broken.rules.json")
+	if err := os.WriteFile(broken, []byte(`{"rules": [{"name": "A", "when": ["TiDBMonitor.CheckWriteHotspot =="], "then": []}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTiDBRuleExecutorWithFiles([]string{broken}, "TiDBHotspot", "1.0.0"); err == nil {
+		t.Errorf("条件语法错误应返回加载错误")
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+	"github.com/hyperjumptech/grule-rule-engine/builder"
+	"github.com/hyperjumptech/grule-rule-engine/engine"
+)
+
+// Facts 命名事实：事实名称 -> 事实指针，执行时逐个加入数据上下文
+type Facts map[string]interface{}
+
+// RuleExecutor 通用规则执行器，负责规则加载、执行、追踪、覆盖率以及影子评估，与具体领域的事实类型无关
+type RuleExecutor struct {
+	knowledgeLibrary *ast.KnowledgeLibrary
+	knowledgeBase    *ast.KnowledgeBase // 只用于读取规则定义（如覆盖率登记），执行使用实例池中的实例
+	ruleEngine       *engine.GruleEngine
+	ruleName         string
+	ruleVersion      string
+	factNames        []string
+	derivedFacts     map[string]func(facts Facts) interface{}
+	rulePacks        []*RulePackManifest
+	ruleSet          RuleSet
+	shadow           *shadowEvaluation
+	libraryLock      sync.Mutex // 保护 knowledgeLibrary 的加载和实例克隆
+	instances        *KnowledgeBasePool
+}
+
+// NewRuleExecutor 创建通用规则执行器，factNames 为执行时必须提供的事实名称，规则包声明的 required_facts 需要包含在其中
+func NewRuleExecutor(ruleSet *RuleSet, ruleName, ruleVersion string, factNames ...string) (*RuleExecutor, error) {
+	if len(factNames) == 0 {
+		return nil, fmt.Errorf("至少需要声明一个事实名称")
+	}
+
+	// 1. 创建知识库
+	knowledgeLibrary := ast.NewKnowledgeLibrary()
+
+	// 2. 加载所有规则到同一个知识库
+	rulePacks, err := buildRuleSet(knowledgeLibrary, ruleSet, ruleName, ruleVersion, factNames)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 获取知识库实例
+	knowledgeBase := knowledgeLibrary.NewKnowledgeBaseInstance(ruleName, ruleVersion)
+
+	// 4. 创建规则引擎
+	ruleEngine := engine.NewGruleEngine()
+
+	executor := &RuleExecutor{
+		knowledgeLibrary: knowledgeLibrary,
+		knowledgeBase:    knowledgeBase,
+		ruleEngine:       ruleEngine,
+		ruleName:         ruleName,
+		ruleVersion:      ruleVersion,
+		factNames:        append([]string{}, factNames...),
+		rulePacks:        rulePacks,
+		ruleSet:          *ruleSet,
+	}
+	executor.instances = newKnowledgeBasePool(executor.newKnowledgeBaseInstance)
+	return executor, nil
+}
+
+// buildRuleSet 将规则集合加载到知识库 ruleName@ruleVersion 中，返回加载的规则包
+func buildRuleSet(knowledgeLibrary *ast.KnowledgeLibrary, ruleSet *RuleSet, ruleName, ruleVersion string, factNames []string) ([]*RulePackManifest, error) {
+	rulePacks, resources, err := ruleSet.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRequiredFacts(rulePacks, factNames); err != nil {
+		return nil, err
+	}
+
+	ruleBuilder := builder.NewRuleBuilder(knowledgeLibrary)
+	for i, resource := range resources {
+		err := ruleBuilder.BuildRuleFromResource(ruleName, ruleVersion, resource)
+		if err != nil {
+			return nil, fmt.Errorf("加载规则 [%d/%d] %s 失败: %v", i+1, len(resources), resource, err)
+		}
+	}
+	return rulePacks, nil
+}
+
+// RuleName 知识库名称
+func (executor *RuleExecutor) RuleName() string {
+	return executor.ruleName
+}
+
+// RuleVersion 生效的知识库版本
+func (executor *RuleExecutor) RuleVersion() string {
+	return executor.ruleVersion
+}
+
+// FactNames 执行器声明的事实名称
+func (executor *RuleExecutor) FactNames() []string {
+	return executor.factNames
+}
+
+// RulePacks 返回执行器加载的规则包（按加载顺序）
+func (executor *RuleExecutor) RulePacks() []*RulePackManifest {
+	return executor.rulePacks
+}
+
+// RuleSet 创建执行器时使用的规则集合
+func (executor *RuleExecutor) RuleSet() RuleSet {
+	return executor.ruleSet
+}
+
+// RuleDescription 规则的描述（GRL 中规则名之后的字符串），规则不存在时为空
+func (executor *RuleExecutor) RuleDescription(ruleName string) string {
+	if entry, ok := executor.knowledgeBase.RuleEntries[ruleName]; ok {
+		return entry.RuleDescription
+	}
+	return ""
+}
+
+// AddDerivedFact 注册派生事实：每次执行时由 derive 根据传入的事实生成并加入数据上下文（如 TiDBMonitor 的聚合函数 Stats），
+// 传入的事实中已有同名事实时不覆盖。派生事实实现 Err() error 且返回错误时，该错误作为执行错误返回。
+// 需要在执行之前注册
+func (executor *RuleExecutor) AddDerivedFact(name string, derive func(facts Facts) interface{}) {
+	if executor.derivedFacts == nil {
+		executor.derivedFacts = make(map[string]func(facts Facts) interface{})
+	}
+	executor.derivedFacts[name] = derive
+}
+
+// InstancePool 并行执行使用的知识库实例池
+func (executor *RuleExecutor) InstancePool() *KnowledgeBasePool {
+	return executor.instances
+}
+
+// AddListener 注册规则引擎监听器，对之后的所有执行生效
+func (executor *RuleExecutor) AddListener(listener engine.GruleEngineListener) {
+	executor.ruleEngine.Listeners = append(executor.ruleEngine.Listeners, listener)
+}
+
+// EnableCoverage 开启覆盖率统计，recorder 可以在多个执行器之间共享
+func (executor *RuleExecutor) EnableCoverage(recorder *CoverageRecorder) {
+	recorder.Register(executor.knowledgeBase)
+	executor.AddListener(recorder)
+}
+
+// Execute 使用命名事实执行规则引擎
+// 每次执行从实例池取出一个已重置的知识库实例，上一次执行中被 Retract 撤回的规则不会影响本次执行
+func (executor *RuleExecutor) Execute(facts Facts) error {
+	knowledgeBase := executor.instances.Get()
+	defer executor.instances.Put(knowledgeBase)
+	return executor.execute(executor.ruleEngine, knowledgeBase, facts)
+}
+
+// ExecuteWithTrace 执行规则引擎并返回按触发顺序排列的规则名称
+func (executor *RuleExecutor) ExecuteWithTrace(facts Facts) ([]string, error) {
+	knowledgeBase := executor.instances.Get()
+	defer executor.instances.Put(knowledgeBase)
+
+	recorder := &firedRuleRecorder{}
+	err := executor.execute(executor.tracingEngine(recorder), knowledgeBase, facts)
+	return recorder.firedRules, err
+}
+
+// ExecuteWithLog 执行规则引擎并输出日志
+func (executor *RuleExecutor) ExecuteWithLog(facts Facts) error {
+	fmt.Println(T("main.executing"))
+	return executor.Execute(facts)
+}
+
+// tracingEngine 复制执行器的引擎配置并附加一个触发记录监听器
+// 每次执行使用独立的监听器，避免多次执行之间的记录互相干扰
+func (executor *RuleExecutor) tracingEngine(recorder *firedRuleRecorder) *engine.GruleEngine {
+	return &engine.GruleEngine{
+		MaxCycle:                        executor.ruleEngine.MaxCycle,
+		ReturnErrOnFailedRuleEvaluation: executor.ruleEngine.ReturnErrOnFailedRuleEvaluation,
+		Listeners:                       append(append([]engine.GruleEngineListener{}, executor.ruleEngine.Listeners...), recorder),
+	}
+}
+
+// execute 校验事实后使用指定的引擎和知识库实例执行规则
+// 执行前显式重置撤回状态和工作内存，不依赖引擎在 Execute 内部是否重置
+func (executor *RuleExecutor) execute(ruleEngine *engine.GruleEngine, knowledgeBase *ast.KnowledgeBase, facts Facts) error {
+	for _, factName := range executor.factNames {
+		if isNilFact(facts[factName]) {
+			return fmt.Errorf("缺少事实 %s", factName)
+		}
+	}
+
+	// 派生事实不修改调用方传入的 facts
+	var derived []string
+	if len(executor.derivedFacts) > 0 {
+		withDerived := make(Facts, len(facts)+len(executor.derivedFacts))
+		for name, fact := range facts {
+			withDerived[name] = fact
+		}
+		for name, derive := range executor.derivedFacts {
+			if _, ok := facts[name]; !ok {
+				withDerived[name] = derive(facts)
+				derived = append(derived, name)
+			}
+		}
+		facts = withDerived
+		sort.Strings(derived)
+	}
+
+	// 按名称排序加入数据上下文，保证执行顺序稳定
+	names := make([]string, 0, len(facts))
+	for name := range facts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dataContext := ast.NewDataContext()
+	for _, name := range names {
+		err := dataContext.Add(name, facts[name])
+		if err != nil {
+			return fmt.Errorf("添加 %s 到数据上下文失败: %v", name, err)
+		}
+	}
+
+	resetKnowledgeBase(knowledgeBase)
+	err := ruleEngine.Execute(dataContext, knowledgeBase)
+	if err != nil {
+		return fmt.Errorf("执行规则失败: %v", err)
+	}
+	for _, name := range derived {
+		if fact, ok := facts[name].(interface{ Err() error }); ok && fact.Err() != nil {
+			return fmt.Errorf("执行规则失败: %v", fact.Err())
+		}
+	}
+	return nil
+}
+
+// isNilFact 事实是否为空（包括类型化的空指针）
+func isNilFact(fact interface{}) bool {
+	if fact == nil {
+		return true
+	}
+	value := reflect.ValueOf(fact)
+	return value.Kind() == reflect.Ptr && value.IsNil()
+}
+
+// firedRuleRecorder 记录规则触发顺序的引擎监听器
+type firedRuleRecorder struct {
+	firedRules []string
+}
+
+// BeginCycle 实现 engine.GruleEngineListener
+func (recorder *firedRuleRecorder) BeginCycle(cycle uint64) {}
+
+// EvaluateRuleEntry 实现 engine.GruleEngineListener
+func (recorder *firedRuleRecorder) EvaluateRuleEntry(cycle uint64, entry *ast.RuleEntry, candidate bool) {
+}
+
+// ExecuteRuleEntry 实现 engine.GruleEngineListener
+func (recorder *firedRuleRecorder) ExecuteRuleEntry(cycle uint64, entry *ast.RuleEntry) {
+	recorder.firedRules = append(recorder.firedRules, entry.RuleName)
+}
+
+// FactExecutor 单一事实类型的规则执行器，事实以 factName 加入数据上下文
+type FactExecutor[T any] struct {
+	*RuleExecutor
+	factName string
+}
+
+// NewFactExecutor 创建单一事实类型的规则执行器
+func NewFactExecutor[T any](ruleSet *RuleSet, ruleName, ruleVersion, factName string) (*FactExecutor[T], error) {
+	executor, err := NewRuleExecutor(ruleSet, ruleName, ruleVersion, factName)
+	if err != nil {
+		return nil, err
+	}
+	return &FactExecutor[T]{RuleExecutor: executor, factName: factName}, nil
+}
+
+// FactName 事实在数据上下文中的名称
+func (executor *FactExecutor[T]) FactName() string {
+	return executor.factName
+}
+
+// Facts 将事实包装为命名事实
+func (executor *FactExecutor[T]) Facts(fact *T) Facts {
+	return Facts{executor.factName: fact}
+}
+
+// Execute 执行规则引擎
+func (executor *FactExecutor[T]) Execute(fact *T) error {
+	return executor.RuleExecutor.Execute(executor.Facts(fact))
+}
+
+// ExecuteWithTrace 执行规则引擎并返回按触发顺序排列的规则名称
+func (executor *FactExecutor[T]) ExecuteWithTrace(fact *T) ([]string, error) {
+	return executor.RuleExecutor.ExecuteWithTrace(executor.Facts(fact))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试用的领域事实
+type testOrder struct {
+	Amount   float64
+	Discount float64
+}
+
+type testCustomer struct {
+	VIP bool
+}
+
+func writeRuleFile(t *testing.T, rules string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.grl")
+	if err := os.WriteFile(path, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRuleExecutorNamedFacts(t *testing.T) {
+	ruleFile := writeRuleFile(t, `rule VIPDiscount "VIP 客户大额订单打折" salience 10 {
+    when
+        Customer.VIP == true && Order.Amount > 100 && Order.Discount == 0
+    then
+        Order.Discount = 0.1;
+        Retract("VIPDiscount");
+}`)
+
+	executor, err := NewRuleExecutor(&RuleSet{Files: []string{ruleFile}}, "Order", "1.0.0", "Order", "Customer")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+
+	order := &testOrder{Amount: 200}
+	firedRules, err := executor.ExecuteWithTrace(Facts{"Order": order, "Customer": &testCustomer{VIP: true}})
+	if err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if order.Discount != 0.1 || len(firedRules) != 1 || firedRules[0] != "VIPDiscount" {
+		t.Errorf("期望 VIPDiscount 触发，实际触发 %v, discount=%v", firedRules, order.Discount)
+	}
+
+	var missing *testCustomer
+	if err := executor.Execute(Facts{"Order": order, "Customer": missing}); err == nil {
+		t.Errorf("缺少事实时应返回错误")
+	}
+}
+
+func TestFactExecutor(t *testing.T) {
+	ruleFile := writeRuleFile(t, `rule BigOrder "大额订单" salience 10 {
+    when
+        Order.Amount > 100 && Order.Discount == 0
+    then
+        Order.Discount = 0.05;
+}`)
+
+	executor, err := NewFactExecutor[testOrder](&RuleSet{Files: []string{ruleFile}}, "Order", "1.0.0", "Order")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+
+	order := &testOrder{Amount: 150}
+	if err := executor.Execute(order); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if order.Discount != 0.05 {
+		t.Errorf("期望 Discount=0.05，实际 %v", order.Discount)
+	}
+}
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+	"github.com/hyperjumptech/grule-rule-engine/builder"
+	"github.com/hyperjumptech/grule-rule-engine/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinRuleFS 编译进二进制的内置规则文件，运行时不再依赖工作目录
+//
+//go:embed tidb.grl tidb_server.grl tikv_thread_pool.grl tidb_workload.grl tidb_topology.grl tikv_node.grl tidb_shard_row_id_bits.table.yaml tidb_shard_row_id_bits.csv rules.grl
+var builtinRuleFS embed.FS
+
+// EngineVersion 规则执行器版本，规则包通过 min_engine_version 声明依赖的最低版本
+const EngineVersion = "1.1.0"
+
+// DefaultRulePack 默认使用的内置规则包
+const DefaultRulePack = "tidb-hotspot"
+
+// rulePackManifestNames 磁盘规则包目录中清单文件的名称
+var rulePackManifestNames = []string{"pack.yaml", "pack.yml", "pack.json"}
+
+// RulePackManifest 规则包清单
+type RulePackManifest struct {
+	Name             string   `json:"name" yaml:"name"`
+	Version          string   `json:"version" yaml:"version"`
+	Description      string   `json:"description" yaml:"description"`
+	RequiredFacts    []string `json:"required_facts" yaml:"required_facts"`         // 规则依赖的数据上下文事实名称，如 TiDBMonitor
+	DependsOn        []string `json:"depends_on" yaml:"depends_on"`                 // 依赖的规则包，格式为 name、name@1.0.0 或 name@>=1.0.0
+	MinEngineVersion string   `json:"min_engine_version" yaml:"min_engine_version"` // 要求的最低 EngineVersion
+	Files            []string `json:"files" yaml:"files"`                           // 规则文件（.grl、决策表 *.table.yaml 或规则定义 *.rules.yaml），相对于清单所在目录
+	Findings         []string `json:"findings" yaml:"findings"`                     // 规则包输出的结论类型，回放历史记录时用于确定对比范围
+
+	// 规则文件来源：内置规则包为 builtinRuleFS，磁盘规则包为清单所在目录
+	source   fs.FS
+	location string
+	embedded bool
+}
+
+// builtinRulePackManifests 内置规则包清单
+var builtinRulePackManifests = []*RulePackManifest{
+	{
+		Name:             "tidb-hotspot",
+		Version:          "1.0.0",
+		Description:      "TiDB 读写热点检测及 SHARD_ROW_ID_BITS 建议",
+		RequiredFacts:    []string{"TiDBMonitor"},
+		MinEngineVersion: "1.0.0",
+		Files:            []string{"tidb.grl", "tidb_shard_row_id_bits.table.yaml"},
+		Findings:         []string{FindingWriteHotspot, FindingReadHotspot, FindingShardRowIDBits},
+	},
+	{
+		Name:             "tidb-server",
+		Version:          "1.0.0",
+		Description:      "TiDB Server（SQL 层）负载均衡倾斜及内存压力 / OOM 风险检测",
+		RequiredFacts:    []string{"TiDBMonitor"},
+		MinEngineVersion: "1.0.0",
+		Files:            []string{"tidb_server.grl"},
+		Findings:         []string{FindingTiDBServerSkew, FindingTiDBServerMemoryPressure, FindingTiDBServerOOMRisk, FindingTiDBServerHighLatency},
+	},
+	{
+		Name:             "tikv-thread-pool",
+		Version:          "1.0.0",
+		Description:      "TiKV 线程池（raftstore / apply / scheduler / gRPC / read pool）饱和检测及线程池大小建议",
+		RequiredFacts:    []string{"TiDBMonitor"},
+		MinEngineVersion: "1.0.0",
+		Files:            []string{"tikv_thread_pool.grl"},
+		Findings:         []string{FindingThreadPoolSaturation},
+	},
+	{
+		Name:             "tidb-workload",
+		Version:          "1.0.0",
+		Description:      "结合 statements_summary 定位写热点来源语句，给出批量写入或主键重新设计建议",
+		RequiredFacts:    []string{"TiDBMonitor"},
+		DependsOn:        []string{"tidb-hotspot@>=1.0.0"},
+		MinEngineVersion: "1.0.0",
+		Files:            []string{"tidb_workload.grl"},
+		Findings:         []string{FindingInsertBatching, FindingWriteKeyRedesign},
+	},
+	{
+		Name:             "tidb-topology",
+		Version:          "1.0.0",
+		Description:      "按 store label（zone / host）区分单个热点 store 与可用区级不均衡",
+		RequiredFacts:    []string{"TiDBMonitor"},
+		DependsOn:        []string{"tidb-hotspot@>=1.0.0"},
+		MinEngineVersion: "1.0.0",
+		Files:            []string{"tidb_topology.grl"},
+		Findings:         []string{FindingZoneWriteImbalance, FindingZoneReadImbalance, FindingIsolatedWriteHotspot, FindingSharedHostHotspot},
+	},
+	{
+		Name:             "tikv-node",
+		Version:          "1.0.0",
+		Description:      "节点级规则：对每个 TiKV 节点执行，检测 Raftstore / Coprocessor CPU 超过集群中位数 2 倍的离群 store",
+		RequiredFacts:    []string{"TiDBMonitor", "TiKVNode", "NodeResult"},
+		MinEngineVersion: "1.0.0",
+		Files:            []string{"tikv_node.grl"},
+		Findings:         []string{FindingNodeCoprocessorOutlier, FindingNodeRaftstoreOutlier},
+	},
+	{
+		Name:             "car",
+		Version:          "1.0.0",
+		Description:      "车辆加减速示例规则",
+		RequiredFacts:    []string{"TestCar", "DistanceRecord"},
+		MinEngineVersion: "1.0.0",
+		Files:            []string{"rules.grl"},
+	},
+}
+
+// Ref 规则包引用，格式为 name@version
+func (manifest *RulePackManifest) Ref() string {
+	return manifest.Name + "@" + manifest.Version
+}
+
+// Location 规则包来源描述
+func (manifest *RulePackManifest) Location() string {
+	if manifest.embedded {
+		return "builtin"
+	}
+	return manifest.location
+}
+
+// validate 校验清单必填字段
+func (manifest *RulePackManifest) validate() error {
+	if manifest.Name == "" {
+		return fmt.Errorf("规则包清单缺少 name（%s）", manifest.location)
+	}
+	if strings.ContainsAny(manifest.Name, "@, ") {
+		return fmt.Errorf("规则包名称 %q 不能包含 @、逗号或空格", manifest.Name)
+	}
+	if _, err := parseVersion(manifest.Version); err != nil {
+		return fmt.Errorf("规则包 %s 的版本无效: %v", manifest.Name, err)
+	}
+	if manifest.MinEngineVersion != "" {
+		if _, err := parseVersion(manifest.MinEngineVersion); err != nil {
+			return fmt.Errorf("规则包 %s 的 min_engine_version 无效: %v", manifest.Name, err)
+		}
+	}
+	for _, dependency := range manifest.DependsOn {
+		if _, err := parsePackRef(dependency); err != nil {
+			return fmt.Errorf("规则包 %s 的依赖无效: %v", manifest.Ref(), err)
+		}
+	}
+	return nil
+}
+
+// Resources 规则包中规则文件对应的资源，overrideDir 中存在同名文件时使用磁盘文件代替
+func (manifest *RulePackManifest) Resources(overrideDir string) ([]pkg.Resource, error) {
+	resources := make([]pkg.Resource, 0, len(manifest.Files))
+	for _, file := range manifest.Files {
+		if isGeneratedRuleFile(file) {
+			resource, err := generatedRuleResource(overrideFS{base: manifest.source, overrideDir: overrideDir}, file)
+			if err != nil {
+				return nil, fmt.Errorf("加载规则包 %s 的规则文件失败: %v", manifest.Ref(), err)
+			}
+			resources = append(resources, resource)
+			continue
+		}
+
+		if overrideDir != "" {
+			overrideFile := filepath.Join(overrideDir, filepath.Base(file))
+			if _, err := os.Stat(overrideFile); err == nil {
+				resources = append(resources, pkg.NewFileResource(overrideFile))
+				continue
+			}
+		}
+
+		if manifest.embedded {
+			resources = append(resources, pkg.NewEmbeddedResource(builtinRuleFS, file))
+			continue
+		}
+		data, err := fs.ReadFile(manifest.source, file)
+		if err != nil {
+			return nil, fmt.Errorf("读取规则包 %s 的规则文件 %s 失败: %v", manifest.Ref(), file, err)
+		}
+		resources = append(resources, pkg.NewBytesResource(data))
+	}
+	return resources, nil
+}
+
+// RulePackRegistry 规则包注册表，同一规则包可以注册多个版本
+type RulePackRegistry struct {
+	packs map[string][]*RulePackManifest
+}
+
+// NewRulePackRegistry 创建空的规则包注册表
+func NewRulePackRegistry() *RulePackRegistry {
+	return &RulePackRegistry{
+		packs: make(map[string][]*RulePackManifest),
+	}
+}
+
+// NewBuiltinRulePackRegistry 创建包含所有内置规则包的注册表
+func NewBuiltinRulePackRegistry() *RulePackRegistry {
+	registry := NewRulePackRegistry()
+	for _, builtin := range builtinRulePackManifests {
+		manifest := *builtin
+		manifest.source = builtinRuleFS
+		manifest.embedded = true
+		if err := registry.Register(&manifest); err != nil {
+			panic(fmt.Sprintf("内置规则包注册失败: %v", err))
+		}
+	}
+	return registry
+}
+
+// Register 注册规则包，同名同版本的规则包不允许重复注册
+func (registry *RulePackRegistry) Register(manifest *RulePackManifest) error {
+	if err := manifest.validate(); err != nil {
+		return err
+	}
+	for _, existing := range registry.packs[manifest.Name] {
+		if existing.Version == manifest.Version {
+			return fmt.Errorf("规则包 %s 已注册（%s）", manifest.Ref(), existing.Location())
+		}
+	}
+
+	registry.packs[manifest.Name] = append(registry.packs[manifest.Name], manifest)
+	sort.Slice(registry.packs[manifest.Name], func(i, j int) bool {
+		return compareVersions(registry.packs[manifest.Name][i].Version, registry.packs[manifest.Name][j].Version) > 0
+	})
+	return nil
+}
+
+// LoadManifestFile 从磁盘加载规则包清单并注册，规则文件相对于清单所在目录
+func (registry *RulePackRegistry) LoadManifestFile(path string) (*RulePackManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取规则包清单 %s 失败: %v", path, err)
+	}
+
+	manifest := &RulePackManifest{}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, manifest)
+	} else {
+		err = yaml.Unmarshal(data, manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析规则包清单 %s 失败: %v", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	manifest.source = os.DirFS(dir)
+	manifest.location = dir
+	if err := registry.Register(manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// LoadDir 递归加载目录下所有名为 pack.yaml / pack.yml / pack.json 的规则包清单
+func (registry *RulePackRegistry) LoadDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("读取规则包目录 %s 失败: %v", dir, err)
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		for _, name := range rulePackManifestNames {
+			if entry.Name() == name {
+				_, err := registry.LoadManifestFile(path)
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Packs 返回所有已注册的规则包，按名称升序、版本降序排列
+func (registry *RulePackRegistry) Packs() []*RulePackManifest {
+	names := make([]string, 0, len(registry.packs))
+	for name := range registry.packs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var manifests []*RulePackManifest
+	for _, name := range names {
+		manifests = append(manifests, registry.packs[name]...)
+	}
+	return manifests
+}
+
+// Lookup 查找满足引用的最高版本规则包
+func (registry *RulePackRegistry) Lookup(ref string) (*RulePackManifest, error) {
+	packRef, err := parsePackRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	return registry.lookup(packRef)
+}
+
+// lookup 查找满足约束的最高版本规则包
+func (registry *RulePackRegistry) lookup(ref packRef) (*RulePackManifest, error) {
+	versions, ok := registry.packs[ref.name]
+	if !ok {
+		names := make([]string, 0, len(registry.packs))
+		for name := range registry.packs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("未知的规则包 %s（可选: %s）", ref.name, strings.Join(names, ", "))
+	}
+
+	for _, manifest := range versions {
+		if ref.matches(manifest.Version) {
+			return manifest, nil
+		}
+	}
+	return nil, fmt.Errorf("没有满足 %s 的规则包版本", ref)
+}
+
+// Resolve 解析规则包及其依赖，返回按依赖顺序排列（依赖在前）的规则包列表
+func (registry *RulePackRegistry) Resolve(refs ...string) ([]*RulePackManifest, error) {
+	resolver := &rulePackResolver{
+		registry: registry,
+		selected: make(map[string]*RulePackManifest),
+		visiting: make(map[string]bool),
+	}
+	for _, ref := range refs {
+		packRef, err := parsePackRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		if err := resolver.resolve(packRef, nil); err != nil {
+			return nil, err
+		}
+	}
+	return resolver.ordered, nil
+}
+
+// LoadIntoLibrary 解析规则包及其依赖，并加载到知识库 name@version 中
+func (registry *RulePackRegistry) LoadIntoLibrary(library *ast.KnowledgeLibrary, name, version, overrideDir string, refs ...string) ([]*RulePackManifest, error) {
+	manifests, err := registry.Resolve(refs...)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleBuilder := builder.NewRuleBuilder(library)
+	for _, manifest := range manifests {
+		resources, err := manifest.Resources(overrideDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			if err := ruleBuilder.BuildRuleFromResource(name, version, resource); err != nil {
+				return nil, fmt.Errorf("加载规则包 %s 失败: %v", manifest.Ref(), err)
+			}
+		}
+	}
+	return manifests, nil
+}
+
+// rulePackResolver 规则包依赖解析状态
+type rulePackResolver struct {
+	registry *RulePackRegistry
+	selected map[string]*RulePackManifest
+	visiting map[string]bool
+	ordered  []*RulePackManifest
+}
+
+// resolve 深度优先解析依赖，同一规则包只能选中一个版本
+func (resolver *rulePackResolver) resolve(ref packRef, path []string) error {
+	if selected, ok := resolver.selected[ref.name]; ok {
+		if !ref.matches(selected.Version) {
+			return fmt.Errorf("规则包版本冲突: 已选择 %s，但 %s 要求 %s", selected.Ref(), strings.Join(path, " -> "), ref)
+		}
+		return nil
+	}
+	if resolver.visiting[ref.name] {
+		return fmt.Errorf("规则包存在循环依赖: %s -> %s", strings.Join(path, " -> "), ref.name)
+	}
+
+	manifest, err := resolver.registry.lookup(ref)
+	if err != nil {
+		if len(path) > 0 {
+			return fmt.Errorf("%s 的依赖无法满足: %v", strings.Join(path, " -> "), err)
+		}
+		return err
+	}
+	if manifest.MinEngineVersion != "" && compareVersions(EngineVersion, manifest.MinEngineVersion) < 0 {
+		return fmt.Errorf("规则包 %s 要求引擎版本 >= %s，当前版本 %s", manifest.Ref(), manifest.MinEngineVersion, EngineVersion)
+	}
+
+	resolver.visiting[ref.name] = true
+	path = append(path, manifest.Ref())
+	for _, dependency := range manifest.DependsOn {
+		dependencyRef, err := parsePackRef(dependency)
+		if err != nil {
+			return err
+		}
+		if err := resolver.resolve(dependencyRef, path); err != nil {
+			return err
+		}
+	}
+	resolver.visiting[ref.name] = false
+
+	resolver.selected[ref.name] = manifest
+	resolver.ordered = append(resolver.ordered, manifest)
+	return nil
+}
+
+// packRef 规则包引用：name、name@1.0.0（精确版本）或 name@>=1.0.0（最低版本）
+type packRef struct {
+	name       string
+	version    string
+	atLeast    bool
+	hasVersion bool
+}
+
+// parsePackRef 解析规则包引用
+func parsePackRef(ref string) (packRef, error) {
+	name, version, hasVersion := strings.Cut(strings.TrimSpace(ref), "@")
+	if name == "" {
+		return packRef{}, fmt.Errorf("规则包引用 %q 缺少名称", ref)
+	}
+	if !hasVersion {
+		return packRef{name: name}, nil
+	}
+
+	parsed := packRef{name: name, hasVersion: true}
+	if strings.HasPrefix(version, ">=") {
+		parsed.atLeast = true
+		version = strings.TrimPrefix(version, ">=")
+	}
+	if _, err := parseVersion(version); err != nil {
+		return packRef{}, fmt.Errorf("规则包引用 %q 的版本无效: %v", ref, err)
+	}
+	parsed.version = version
+	return parsed, nil
+}
+
+// matches 版本是否满足引用约束
+func (ref packRef) matches(version string) bool {
+	if !ref.hasVersion {
+		return true
+	}
+	if ref.atLeast {
+		return compareVersions(version, ref.version) >= 0
+	}
+	return compareVersions(version, ref.version) == 0
+}
+
+// String 引用的文本形式
+func (ref packRef) String() string {
+	switch {
+	case !ref.hasVersion:
+		return ref.name
+	case ref.atLeast:
+		return ref.name + "@>=" + ref.version
+	default:
+		return ref.name + "@" + ref.version
+	}
+}
+
+// parseVersion 解析 MAJOR.MINOR.PATCH 格式的版本号（允许 v 前缀，缺省部分为 0）
+func parseVersion(version string) ([3]int, error) {
+	var parsed [3]int
+	trimmed := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if trimmed == "" {
+		return parsed, fmt.Errorf("版本号为空")
+	}
+
+	parts := strings.Split(trimmed, ".")
+	if len(parts) > 3 {
+		return parsed, fmt.Errorf("版本号 %q 格式错误", version)
+	}
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 {
+			return parsed, fmt.Errorf("版本号 %q 格式错误", version)
+		}
+		parsed[i] = value
+	}
+	return parsed, nil
+}
+
+// compareVersions 比较两个版本号，a < b 返回 -1，相等返回 0，a > b 返回 1（无效版本视为 0.0.0）
+func compareVersions(a, b string) int {
+	va, _ := parseVersion(a)
+	vb, _ := parseVersion(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// RuleSet 规则来源：规则包（可被磁盘文件覆盖）加上额外的磁盘规则文件
+type RuleSet struct {
+	Packs       []string `json:"packs,omitempty"`        // 规则包引用，如 tidb-hotspot 或 tidb-hotspot@1.0.0，依赖会被自动加载
+	PackDirs    []string `json:"pack_dirs,omitempty"`    // 额外的磁盘规则包目录，与内置规则包一起注册
+	OverrideDir string   `json:"override_dir,omitempty"` // 不为空且目录中存在同名文件时，使用磁盘文件代替规则包中的规则文件
+	Files       []string `json:"files,omitempty"`        // 额外的磁盘规则文件（.grl、决策表 *.table.yaml 或规则定义 *.rules.yaml），在规则包之后加载，用于扩展规则
+}
+
+// Registry 构造规则集合使用的规则包注册表：内置规则包加上 PackDirs 中的磁盘规则包
+func (ruleSet *RuleSet) Registry() (*RulePackRegistry, error) {
+	registry := NewBuiltinRulePackRegistry()
+	for _, dir := range ruleSet.PackDirs {
+		if err := registry.LoadDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// Resolve 解析规则集合，返回选中的规则包以及按加载顺序排列的规则资源
+func (ruleSet *RuleSet) Resolve() ([]*RulePackManifest, []pkg.Resource, error) {
+	var manifests []*RulePackManifest
+	var resources []pkg.Resource
+
+	if len(ruleSet.Packs) > 0 {
+		registry, err := ruleSet.Registry()
+		if err != nil {
+			return nil, nil, err
+		}
+		manifests, err = registry.Resolve(ruleSet.Packs...)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, manifest := range manifests {
+			packResources, err := manifest.Resources(ruleSet.OverrideDir)
+			if err != nil {
+				return nil, nil, err
+			}
+			resources = append(resources, packResources...)
+		}
+	}
+
+	for _, ruleFile := range ruleSet.Files {
+		if isGeneratedRuleFile(ruleFile) {
+			resource, err := generatedRuleResource(os.DirFS(filepath.Dir(ruleFile)), filepath.Base(ruleFile))
+			if err != nil {
+				return nil, nil, err
+			}
+			resources = append(resources, resource)
+			continue
+		}
+		resources = append(resources, pkg.NewFileResource(ruleFile))
+	}
+
+	if len(resources) == 0 {
+		return nil, nil, fmt.Errorf("至少需要提供一个规则包或规则文件")
+	}
+	return manifests, resources, nil
+}
+
+// String 规则集合的描述，同时用作执行器缓存的键
+func (ruleSet *RuleSet) String() string {
+	return fmt.Sprintf("packs=%s dirs=%s override=%s files=%s",
+		strings.Join(ruleSet.Packs, ","), strings.Join(ruleSet.PackDirs, ","),
+		ruleSet.OverrideDir, strings.Join(ruleSet.Files, ","))
+}
+
+// checkRequiredFacts 校验规则包依赖的事实都由执行器提供
+func checkRequiredFacts(manifests []*RulePackManifest, providedFacts []string) error {
+	provided := make(map[string]bool, len(providedFacts))
+	for _, fact := range providedFacts {
+		provided[fact] = true
+	}
+	for _, manifest := range manifests {
+		for _, fact := range manifest.RequiredFacts {
+			if !provided[fact] {
+				return fmt.Errorf("规则包 %s 需要事实 %s，但执行器只提供 %s",
+					manifest.Ref(), fact, strings.Join(providedFacts, ", "))
+			}
+		}
+	}
+	return nil
+}
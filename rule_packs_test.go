@@ -0,0 +1,217 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 内置规则包不依赖工作目录
+func TestBuiltinRulePackOutsideModuleRoot(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+
+	monitor := &TiDBMonitor{
+		CheckWriteHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 30.0},
+			{NodeID: "tikv-2", RaftstoreCPU: 30.0},
+			{NodeID: "tikv-3", RaftstoreCPU: 90.0},
+		},
+	}
+	monitor.CalculateStatistics()
+	if err := executor.Execute(monitor); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if !monitor.WriteHotspotDetected {
+		t.Errorf("期望检测到写热点")
+	}
+}
+
+func TestRuleSetOverrideAndExtend(t *testing.T) {
+	overrideDir := t.TempDir()
+	override := `rule DetectWriteHotspot "覆盖后的写热点规则" salience 10 {
+    when
+        TiDBMonitor.CheckWriteHotspot == true && TiDBMonitor.WriteHotspotDetected == false
+    then
+        TiDBMonitor.WriteHotspotDetected = true;
+        TiDBMonitor.WriteHotspotRatio = 42;
+}`
+	if err := os.WriteFile(filepath.Join(overrideDir, "tidb.grl"), []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+	extraFile := filepath.Join(t.TempDir(), "extra.grl")
+	extra := `rule ExtraShardBits "扩展规则" salience 1 {
+    when
+        TiDBMonitor.WriteHotspotDetected == true && TiDBMonitor.ShardRowIDBits == 0
+    then
+        TiDBMonitor.ShardRowIDBits = 4;
+}`
+	if err := os.WriteFile(extraFile, []byte(extra), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ruleSet := &RuleSet{Packs: []string{DefaultRulePack}, OverrideDir: overrideDir, Files: []string{extraFile}}
+	executor, err := NewTiDBRuleExecutorWithRuleSet(ruleSet, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+
+	monitor := &TiDBMonitor{CheckWriteHotspot: true}
+	firedRules, err := executor.ExecuteWithTrace(monitor)
+	if err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if monitor.WriteHotspotRatio != 42 || monitor.ShardRowIDBits != 4 {
+		t.Errorf("期望覆盖规则和扩展规则都生效，实际触发 %v, ratio=%v, bits=%d",
+			firedRules, monitor.WriteHotspotRatio, monitor.ShardRowIDBits)
+	}
+}
+
+func TestUnknownRulePack(t *testing.T) {
+	if _, err := NewTiDBRuleExecutorWithPack("no-such-pack", nil, "TiDBHotspot", "1.0.0"); err == nil {
+		t.Fatalf("期望未知规则包返回错误")
+	}
+}
+
+// writeRulePack 在 dir/name-version 下写入规则包清单和规则文件
+func writeRulePack(t *testing.T, dir, manifest, ruleFile, rules string) {
+	t.Helper()
+	packDir, err := os.MkdirTemp(dir, "pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(packDir, "pack.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ruleFile != "" {
+		if err := os.WriteFile(filepath.Join(packDir, ruleFile), []byte(rules), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRulePackRegistryResolve(t *testing.T) {
+	dir := t.TempDir()
+	writeRulePack(t, dir, `
+name: write-hotspot
+version: 1.0.0
+required_facts: [TiDBMonitor]
+files: [write.grl]
+`, "write.grl", `rule TeamWriteHotspot "v1" salience 10 {
+    when TiDBMonitor.WriteHotspotDetected == false && TiDBMonitor.CheckWriteHotspot == true
+    then TiDBMonitor.WriteHotspotDetected = true; TiDBMonitor.ShardRowIDBits = 1;
+}`)
+	writeRulePack(t, dir, `
+name: write-hotspot
+version: 1.2.0
+required_facts: [TiDBMonitor]
+files: [write.grl]
+`, "write.grl", `rule TeamWriteHotspot "v1.2" salience 10 {
+    when TiDBMonitor.WriteHotspotDetected == false && TiDBMonitor.CheckWriteHotspot == true
+    then TiDBMonitor.WriteHotspotDetected = true; TiDBMonitor.ShardRowIDBits = 2;
+}`)
+	writeRulePack(t, dir, `
+name: capacity
+version: 0.1.0
+depends_on: [write-hotspot@>=1.1.0]
+required_facts: [TiDBMonitor]
+files: [capacity.grl]
+`, "capacity.grl", `rule TeamCapacity "capacity" salience 1 {
+    when TiDBMonitor.WriteHotspotDetected == true && TiDBMonitor.ShardRowIDBits < 10
+    then TiDBMonitor.ShardRowIDBits = TiDBMonitor.ShardRowIDBits + 10;
+}`)
+
+	registry := NewBuiltinRulePackRegistry()
+	if err := registry.LoadDir(dir); err != nil {
+		t.Fatalf("加载规则包目录失败: %v", err)
+	}
+
+	manifests, err := registry.Resolve("capacity")
+	if err != nil {
+		t.Fatalf("解析规则包失败: %v", err)
+	}
+	var refs []string
+	for _, manifest := range manifests {
+		refs = append(refs, manifest.Ref())
+	}
+	if len(refs) != 2 || refs[0] != "write-hotspot@1.2.0" || refs[1] != "capacity@0.1.0" {
+		t.Fatalf("期望依赖在前且选择最高版本，实际 %v", refs)
+	}
+
+	if _, err := registry.Resolve("write-hotspot@1.0.0", "capacity"); err == nil {
+		t.Errorf("期望版本冲突返回错误")
+	}
+	if _, err := registry.Resolve("write-hotspot@2.0.0"); err == nil {
+		t.Errorf("期望不存在的版本返回错误")
+	}
+
+	executor, err := NewTiDBRuleExecutorWithRuleSet(&RuleSet{Packs: []string{"capacity"}, PackDirs: []string{dir}}, "Team", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	if len(executor.RulePacks()) != 2 {
+		t.Errorf("期望加载 2 个规则包，实际 %d 个", len(executor.RulePacks()))
+	}
+	monitor := &TiDBMonitor{CheckWriteHotspot: true}
+	if err := executor.Execute(monitor); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if monitor.ShardRowIDBits != 12 {
+		t.Errorf("期望 ShardRowIDBits=12（1.2.0 版本规则 + capacity 规则），实际 %d", monitor.ShardRowIDBits)
+	}
+}
+
+func TestRulePackRegistryErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeRulePack(t, dir, "name: a\nversion: 1.0.0\ndepends_on: [b]\n", "", "")
+	writeRulePack(t, dir, "name: b\nversion: 1.0.0\ndepends_on: [a]\n", "", "")
+	writeRulePack(t, dir, "name: future\nversion: 1.0.0\nmin_engine_version: 99.0.0\n", "", "")
+
+	registry := NewRulePackRegistry()
+	if err := registry.LoadDir(dir); err != nil {
+		t.Fatalf("加载规则包目录失败: %v", err)
+	}
+	if _, err := registry.Resolve("a"); err == nil {
+		t.Errorf("期望循环依赖返回错误")
+	}
+	if _, err := registry.Resolve("future"); err == nil {
+		t.Errorf("期望引擎版本不满足时返回错误")
+	}
+	if err := registry.Register(&RulePackManifest{Name: "a", Version: "1.0.0"}); err == nil {
+		t.Errorf("期望重复注册返回错误")
+	}
+
+	// car 规则包需要 TestCar / DistanceRecord，TiDB 执行器不提供
+	if _, err := NewTiDBRuleExecutorWithPack("car", nil, "Car", "1.0.0"); err == nil {
+		t.Errorf("期望缺少事实时返回错误")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.2", "1.2.0", 0},
+		{"v1.10.0", "1.9.3", 1},
+		{"0.9.9", "1.0.0", -1},
+	}
+	for _, c := range cases {
+		if got := compareVersions(c.a, c.b); got != c.want {
+			t.Errorf("compareVersions(%s, %s) = %d, 期望 %d", c.a, c.b, got, c.want)
+		}
+	}
+}
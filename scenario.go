@@ -0,0 +1,415 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFieldTolerance 数值字段比较的默认容差（规则输出一般按两位小数阅读）
+const defaultFieldTolerance = 0.01
+
+// Scenario 声明式测试场景：输入节点和标志、期望触发的规则、期望的输出字段
+type Scenario struct {
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description" yaml:"description"`
+	RulePacks   []string       `json:"rule_packs" yaml:"rule_packs"` // 内置规则包名称
+	RuleFiles   []string       `json:"rule_files" yaml:"rule_files"` // 磁盘规则文件，相对路径以场景文件所在目录为基准
+	Input       ScenarioInput  `json:"input" yaml:"input"`
+	Expect      ScenarioExpect `json:"expect" yaml:"expect"`
+
+	NodeRulePacks []string `json:"node_rule_packs" yaml:"node_rule_packs"` // 节点级规则包，在集群级规则之后对每个 TiKV 节点执行
+
+	// 场景文件路径，由加载器填充
+	file string
+}
+
+// ScenarioInput 场景输入数据
+type ScenarioInput struct {
+	CheckWriteHotspot          bool        `json:"check_write_hotspot" yaml:"check_write_hotspot"`
+	CheckReadHotspot           bool        `json:"check_read_hotspot" yaml:"check_read_hotspot"`
+	IsNonClusteredIndexHotspot bool        `json:"is_non_clustered_index_hotspot" yaml:"is_non_clustered_index_hotspot"`
+	Nodes                      []*TiKVNode `json:"nodes" yaml:"nodes"`
+
+	CheckTopology bool `json:"check_topology" yaml:"check_topology"` // 节点需要提供 labels（zone / host）
+	NormalizeCPU  bool `json:"normalize_cpu" yaml:"normalize_cpu"`   // 按 CPU 核数和线程池大小归一化后再比较
+
+	CheckTiDBServer bool              `json:"check_tidb_server" yaml:"check_tidb_server"`
+	TiDBServers     []*TiDBServerNode `json:"tidb_servers" yaml:"tidb_servers"`
+
+	CheckTiKVThreadPools bool `json:"check_tikv_thread_pools" yaml:"check_tikv_thread_pools"`
+
+	CheckWorkload bool                `json:"check_workload" yaml:"check_workload"`
+	Statements    []*StatementSummary `json:"statements" yaml:"statements"` // statements_summary 行，同一 Digest 会被合并
+}
+
+// ScenarioExpect 场景期望结果
+type ScenarioExpect struct {
+	FiredRules    []string               `json:"fired_rules" yaml:"fired_rules"`         // 必须触发的规则（不要求顺序）
+	NotFiredRules []string               `json:"not_fired_rules" yaml:"not_fired_rules"` // 不允许触发的规则
+	Fields        map[string]interface{} `json:"fields" yaml:"fields"`                   // TiDBMonitor 字段名（嵌套字段用 . 分隔）-> 期望值
+	Findings      []string               `json:"findings" yaml:"findings"`               // 必须出现的结论标识，如 node_raftstore_outlier@tikv-3
+	Tolerance     float64                `json:"tolerance" yaml:"tolerance"`             // 数值字段容差，为 0 时使用 defaultFieldTolerance
+}
+
+// ScenarioResult 单个场景的执行结果
+type ScenarioResult struct {
+	Scenario   *Scenario
+	FiredRules []string
+	Monitor    *TiDBMonitor
+	Failures   []string
+	Err        error
+}
+
+// Passed 场景是否通过
+func (result *ScenarioResult) Passed() bool {
+	return result.Err == nil && len(result.Failures) == 0
+}
+
+// BuildMonitor 根据场景输入构造 TiDBMonitor 并计算统计信息
+func (scenario *Scenario) BuildMonitor() *TiDBMonitor {
+	return scenario.Input.BuildMonitor()
+}
+
+// BuildMonitor 根据输入数据构造 TiDBMonitor 并计算统计信息，节点和语句都会复制
+func (input ScenarioInput) BuildMonitor() *TiDBMonitor {
+	nodes := make([]*TiKVNode, 0, len(input.Nodes))
+	for _, node := range input.Nodes {
+		copied := *node
+		nodes = append(nodes, &copied)
+	}
+
+	servers := make([]*TiDBServerNode, 0, len(input.TiDBServers))
+	for _, server := range input.TiDBServers {
+		copied := *server
+		servers = append(servers, &copied)
+	}
+
+	monitor := &TiDBMonitor{
+		CheckWriteHotspot:          input.CheckWriteHotspot,
+		CheckReadHotspot:           input.CheckReadHotspot,
+		IsNonClusteredIndexHotspot: input.IsNonClusteredIndexHotspot,
+		TiKVNodes:                  nodes,
+		CheckTopology:              input.CheckTopology,
+		NormalizeCPU:               input.NormalizeCPU,
+		CheckTiDBServer:            input.CheckTiDBServer,
+		TiDBServers:                servers,
+		CheckTiKVThreadPools:       input.CheckTiKVThreadPools,
+		CheckWorkload:              input.CheckWorkload,
+	}
+	monitor.AttachStatements(input.Statements)
+	monitor.CalculateStatistics()
+	return monitor
+}
+
+// LoadScenarioFile 加载单个场景文件（.yaml / .yml / .json）
+func LoadScenarioFile(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取场景文件 %s 失败: %v", path, err)
+	}
+
+	scenario := &Scenario{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, scenario)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, scenario)
+	default:
+		return nil, fmt.Errorf("不支持的场景文件格式: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析场景文件 %s 失败: %v", path, err)
+	}
+
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	for _, node := range scenario.Input.Nodes {
+		if node == nil {
+			return nil, fmt.Errorf("场景文件 %s 包含空节点", path)
+		}
+	}
+	scenario.file = path
+	return scenario, nil
+}
+
+// LoadScenarios 加载路径下的所有场景，path 可以是单个文件或目录
+func LoadScenarios(path string) ([]*Scenario, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取场景路径 %s 失败: %v", path, err)
+	}
+	if !info.IsDir() {
+		scenario, err := LoadScenarioFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []*Scenario{scenario}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取场景目录 %s 失败: %v", path, err)
+	}
+
+	scenarios := make([]*Scenario, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		scenario, err := LoadScenarioFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	return scenarios, nil
+}
+
+// ScenarioRunner 场景运行器，按规则集合缓存规则执行器
+type ScenarioRunner struct {
+	RuleSet     *RuleSet // 场景未指定 rule_packs / rule_files 时使用的默认规则集合
+	RuleName    string
+	RuleVersion string
+	Coverage    *CoverageRecorder // 不为空时记录所有场景的规则覆盖率
+
+	executors     map[string]*TiDBRuleExecutor
+	nodeExecutors map[string]*TiKVNodeRuleExecutor
+}
+
+// NewScenarioRunner 创建场景运行器
+func NewScenarioRunner(ruleSet *RuleSet, ruleName, ruleVersion string) *ScenarioRunner {
+	return &ScenarioRunner{
+		RuleSet:       ruleSet,
+		RuleName:      ruleName,
+		RuleVersion:   ruleVersion,
+		executors:     make(map[string]*TiDBRuleExecutor),
+		nodeExecutors: make(map[string]*TiKVNodeRuleExecutor),
+	}
+}
+
+// executorFor 获取场景对应的规则执行器
+func (runner *ScenarioRunner) executorFor(scenario *Scenario) (*TiDBRuleExecutor, error) {
+	ruleSet := runner.RuleSet
+	if len(scenario.RulePacks) > 0 || len(scenario.RuleFiles) > 0 {
+		ruleSet = &RuleSet{Packs: scenario.RulePacks, OverrideDir: runner.RuleSet.OverrideDir}
+		for _, ruleFile := range scenario.RuleFiles {
+			if !filepath.IsAbs(ruleFile) && scenario.file != "" {
+				ruleFile = filepath.Join(filepath.Dir(scenario.file), ruleFile)
+			}
+			ruleSet.Files = append(ruleSet.Files, ruleFile)
+		}
+	}
+
+	key := ruleSet.String()
+	if executor, ok := runner.executors[key]; ok {
+		return executor, nil
+	}
+	executor, err := NewTiDBRuleExecutorWithRuleSet(ruleSet, runner.RuleName, runner.RuleVersion)
+	if err != nil {
+		return nil, err
+	}
+	if runner.Coverage != nil {
+		executor.EnableCoverage(runner.Coverage)
+	}
+	runner.executors[key] = executor
+	return executor, nil
+}
+
+// nodeExecutorFor 获取场景对应的节点级规则执行器，场景没有 node_rule_packs 时返回 nil
+func (runner *ScenarioRunner) nodeExecutorFor(scenario *Scenario) (*TiKVNodeRuleExecutor, error) {
+	if len(scenario.NodeRulePacks) == 0 {
+		return nil, nil
+	}
+	ruleSet := &RuleSet{Packs: scenario.NodeRulePacks, OverrideDir: runner.RuleSet.OverrideDir}
+	key := ruleSet.String()
+	if executor, ok := runner.nodeExecutors[key]; ok {
+		return executor, nil
+	}
+	executor, err := NewTiKVNodeRuleExecutorWithRuleSet(ruleSet, runner.RuleName+"Node", runner.RuleVersion)
+	if err != nil {
+		return nil, err
+	}
+	if runner.Coverage != nil {
+		executor.EnableCoverage(runner.Coverage)
+	}
+	runner.nodeExecutors[key] = executor
+	return executor, nil
+}
+
+// Run 执行单个场景并校验期望结果
+func (runner *ScenarioRunner) Run(scenario *Scenario) *ScenarioResult {
+	result := &ScenarioResult{Scenario: scenario}
+
+	executor, err := runner.executorFor(scenario)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Monitor = scenario.BuildMonitor()
+	result.FiredRules, err = executor.ExecuteWithTrace(result.Monitor)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	nodeExecutor, err := runner.nodeExecutorFor(scenario)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if nodeExecutor != nil {
+		if _, err := nodeExecutor.EvaluateNodes(result.Monitor); err != nil {
+			result.Err = err
+			return result
+		}
+		result.FiredRules = append(result.FiredRules, result.Monitor.NodeFiredRules()...)
+	}
+
+	result.Failures = scenario.Expect.Check(result.Monitor, result.FiredRules)
+	return result
+}
+
+// RunAll 依次执行所有场景
+func (runner *ScenarioRunner) RunAll(scenarios []*Scenario) []*ScenarioResult {
+	results := make([]*ScenarioResult, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		results = append(results, runner.Run(scenario))
+	}
+	return results
+}
+
+// Check 校验执行结果，返回所有不满足的期望
+func (expect *ScenarioExpect) Check(monitor *TiDBMonitor, firedRules []string) []string {
+	var failures []string
+
+	fired := make(map[string]bool, len(firedRules))
+	for _, ruleName := range firedRules {
+		fired[ruleName] = true
+	}
+	for _, ruleName := range expect.FiredRules {
+		if !fired[ruleName] {
+			failures = append(failures, fmt.Sprintf("期望规则 %s 触发，但未触发（实际触发: %v）", ruleName, firedRules))
+		}
+	}
+	for _, ruleName := range expect.NotFiredRules {
+		if fired[ruleName] {
+			failures = append(failures, fmt.Sprintf("期望规则 %s 不触发，但实际触发了", ruleName))
+		}
+	}
+
+	if len(expect.Findings) > 0 {
+		findings := make(map[string]bool)
+		var keys []string
+		for _, finding := range monitor.Findings() {
+			findings[finding.Key()] = true
+			keys = append(keys, finding.Key())
+		}
+		for _, key := range expect.Findings {
+			if !findings[key] {
+				failures = append(failures, fmt.Sprintf("期望结论 %s，但未出现（实际结论: %v）", key, keys))
+			}
+		}
+	}
+
+	tolerance := expect.Tolerance
+	if tolerance == 0 {
+		tolerance = defaultFieldTolerance
+	}
+
+	fieldNames := make([]string, 0, len(expect.Fields))
+	for fieldName := range expect.Fields {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+
+	monitorValue := reflect.ValueOf(monitor).Elem()
+	for _, fieldName := range fieldNames {
+		field := monitorValue
+		for _, name := range strings.Split(fieldName, ".") {
+			if field.Kind() == reflect.Ptr && !field.IsNil() {
+				field = field.Elem()
+			}
+			if field.Kind() != reflect.Struct {
+				field = reflect.Value{}
+				break
+			}
+			field = field.FieldByName(name)
+		}
+		if !field.IsValid() {
+			failures = append(failures, fmt.Sprintf("TiDBMonitor 不存在字段 %s", fieldName))
+			continue
+		}
+		if msg := compareField(fieldName, field, expect.Fields[fieldName], tolerance); msg != "" {
+			failures = append(failures, msg)
+		}
+	}
+	return failures
+}
+
+// compareField 比较字段实际值与期望值，一致时返回空字符串
+func compareField(fieldName string, actual reflect.Value, expected interface{}, tolerance float64) string {
+	switch actual.Kind() {
+	case reflect.Bool:
+		want, ok := expected.(bool)
+		if !ok {
+			return fmt.Sprintf("字段 %s 的期望值 %v 不是布尔值", fieldName, expected)
+		}
+		if actual.Bool() != want {
+			return fmt.Sprintf("字段 %s 期望 %v，实际 %v", fieldName, want, actual.Bool())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64:
+		want, ok := toFloat64(expected)
+		if !ok {
+			return fmt.Sprintf("字段 %s 的期望值 %v 不是数值", fieldName, expected)
+		}
+		var got float64
+		if actual.Kind() == reflect.Float32 || actual.Kind() == reflect.Float64 {
+			got = actual.Float()
+		} else {
+			got = float64(actual.Int())
+		}
+		if math.Abs(got-want) > tolerance {
+			return fmt.Sprintf("字段 %s 期望 %v，实际 %v", fieldName, want, got)
+		}
+	case reflect.String:
+		want, ok := expected.(string)
+		if !ok {
+			return fmt.Sprintf("字段 %s 的期望值 %v 不是字符串", fieldName, expected)
+		}
+		if actual.String() != want {
+			return fmt.Sprintf("字段 %s 期望 %q，实际 %q", fieldName, want, actual.String())
+		}
+	default:
+		return fmt.Sprintf("字段 %s 的类型 %s 不支持比较", fieldName, actual.Kind())
+	}
+	return ""
+}
+
+// toFloat64 将 JSON/YAML 解析出的数值转换为 float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+)
+
+// runScenarioTests 加载路径下的所有场景，并以子测试的形式逐个运行
+func runScenarioTests(t *testing.T, path string, ruleFiles ...string) {
+	t.Helper()
+
+	if len(ruleFiles) == 0 {
+		ruleFiles = tidbHotspotRuleFiles
+	}
+
+	scenarios, err := LoadScenarios(path)
+	if err != nil {
+		t.Fatalf("加载场景失败: %v", err)
+	}
+	if len(scenarios) == 0 {
+		t.Fatalf("%s 下没有场景文件", path)
+	}
+
+	runner := NewScenarioRunner(&RuleSet{Files: ruleFiles}, "TiDBHotspot", "1.0.0")
+	for _, scenario := range scenarios {
+		scenario := scenario
+		t.Run(scenario.Name, func(t *testing.T) {
+			result := runner.Run(scenario)
+			if result.Err != nil {
+				t.Fatalf("执行场景失败: %v", result.Err)
+			}
+			for _, failure := range result.Failures {
+				t.Error(failure)
+			}
+		})
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	runScenarioTests(t, "scenarios")
+}
+
+func TestScenarioExpectCheck(t *testing.T) {
+	monitor := &TiDBMonitor{WriteHotspotDetected: true, WriteHotspotRatio: 2.354, ShardRowIDBits: 10, WriteHotspotNode: "tikv-3"}
+	expect := &ScenarioExpect{
+		FiredRules:    []string{"DetectWriteHotspot"},
+		NotFiredRules: []string{"NoWriteHotspot"},
+		Fields: map[string]interface{}{
+			"WriteHotspotDetected": true,
+			"WriteHotspotRatio":    2.35,
+			"ShardRowIDBits":       10,
+			"WriteHotspotNode":     "tikv-3",
+		},
+	}
+
+	if failures := expect.Check(monitor, []string{"DetectWriteHotspot"}); len(failures) != 0 {
+		t.Fatalf("期望校验通过，实际失败: %v", failures)
+	}
+
+	expect.Fields["ShardRowIDBits"] = 12
+	expect.Fields["Unknown"] = 1
+	failures := expect.Check(monitor, []string{"NoWriteHotspot"})
+	if len(failures) != 4 {
+		t.Fatalf("期望 4 个失败项，实际 %d 个: %v", len(failures), failures)
+	}
+}
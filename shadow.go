@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+	"github.com/hyperjumptech/grule-rule-engine/engine"
+)
+
+// shadowEvaluation 影子评估使用的候选规则版本
+type shadowEvaluation struct {
+	version   string
+	rulePacks []*RulePackManifest
+	instances *KnowledgeBasePool
+}
+
+// ShadowResult 影子评估结果：生效版本与候选版本在同一份监控数据上的执行结果及结论差异
+type ShadowResult struct {
+	ActiveVersion       string
+	CandidateVersion    string
+	ActiveFiredRules    []string
+	CandidateFiredRules []string
+	ActiveFindings      []Finding
+	CandidateFindings   []Finding
+	Diffs               []FindingDiff
+	CandidateMonitor    *TiDBMonitor // 候选版本执行后的监控数据副本
+	CandidateErr        error        // 候选版本执行失败不影响生效版本的结果
+}
+
+// HasDiff 候选版本与生效版本的结论是否存在差异
+func (result *ShadowResult) HasDiff() bool {
+	return len(result.Diffs) > 0
+}
+
+// LoadShadowVersion 将候选版本规则加载到执行器的知识库中（与生效版本同名不同版本），开启影子评估
+func (executor *RuleExecutor) LoadShadowVersion(candidateVersion string, ruleSet *RuleSet) error {
+	if candidateVersion == executor.ruleVersion {
+		return fmt.Errorf("候选版本 %s 不能与生效版本相同", candidateVersion)
+	}
+
+	executor.libraryLock.Lock()
+	defer executor.libraryLock.Unlock()
+
+	rulePacks, err := buildRuleSet(executor.knowledgeLibrary, ruleSet, executor.ruleName, candidateVersion, executor.factNames)
+	if err != nil {
+		return fmt.Errorf("加载候选版本 %s 失败: %v", candidateVersion, err)
+	}
+
+	executor.shadow = &shadowEvaluation{
+		version:   candidateVersion,
+		rulePacks: rulePacks,
+		instances: newKnowledgeBasePool(func() *ast.KnowledgeBase {
+			return executor.newShadowInstance(candidateVersion)
+		}),
+	}
+	return nil
+}
+
+// newShadowInstance 从候选版本的知识库蓝图创建一个新的实例，与 newKnowledgeBaseInstance 一样串行化对 KnowledgeLibrary 的访问
+func (executor *RuleExecutor) newShadowInstance(candidateVersion string) *ast.KnowledgeBase {
+	executor.libraryLock.Lock()
+	defer executor.libraryLock.Unlock()
+	return executor.knowledgeLibrary.NewKnowledgeBaseInstance(executor.ruleName, candidateVersion)
+}
+
+// ShadowVersion 当前的候选版本，未开启影子评估时返回空字符串
+func (executor *RuleExecutor) ShadowVersion() string {
+	if executor.shadow == nil {
+		return ""
+	}
+	return executor.shadow.version
+}
+
+// ExecuteShadow 使用候选版本执行规则，返回按触发顺序排列的规则名称
+// 每次执行从候选版本的实例池取出独立的实例，并发调用之间不共享工作内存；
+// 引擎配置与生效版本一致，但不使用执行器注册的监听器，避免影响覆盖率等统计
+func (executor *RuleExecutor) ExecuteShadow(facts Facts) ([]string, error) {
+	if executor.shadow == nil {
+		return nil, fmt.Errorf("未加载候选版本，请先调用 LoadShadowVersion")
+	}
+
+	knowledgeBase := executor.shadow.instances.Get()
+	defer executor.shadow.instances.Put(knowledgeBase)
+	recorder := &firedRuleRecorder{}
+	ruleEngine := &engine.GruleEngine{
+		MaxCycle:                        executor.ruleEngine.MaxCycle,
+		ReturnErrOnFailedRuleEvaluation: executor.ruleEngine.ReturnErrOnFailedRuleEvaluation,
+		Listeners:                       []engine.GruleEngineListener{recorder},
+	}
+	err := executor.execute(ruleEngine, knowledgeBase, facts)
+	return recorder.firedRules, err
+}
+
+// ExecuteWithShadow 生效版本在 monitor 上执行，候选版本并行地在 monitor 的深拷贝上执行，返回两者的结论差异
+func (executor *TiDBRuleExecutor) ExecuteWithShadow(monitor *TiDBMonitor) (*ShadowResult, error) {
+	if executor.shadow == nil {
+		return nil, fmt.Errorf("未加载候选版本，请先调用 LoadShadowVersion")
+	}
+
+	result := &ShadowResult{
+		ActiveVersion:    executor.ruleVersion,
+		CandidateVersion: executor.shadow.version,
+		CandidateMonitor: monitor.Clone(),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result.CandidateFiredRules, result.CandidateErr = executor.ExecuteShadow(executor.Facts(result.CandidateMonitor))
+	}()
+
+	var err error
+	result.ActiveFiredRules, err = executor.ExecuteWithTrace(monitor)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	result.ActiveFindings = monitor.Findings()
+	if result.CandidateErr == nil {
+		result.CandidateFindings = result.CandidateMonitor.Findings()
+		result.Diffs = DiffFindings(result.ActiveFindings, result.CandidateFindings)
+	}
+	return result, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeCandidateRules 基于内置 tidb.grl 生成阈值从 1.5 倍降为 1.3 倍的候选规则文件
+func writeCandidateRules(t *testing.T) string {
+	t.Helper()
+	data, err := builtinRuleFS.ReadFile("tidb.grl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidate := strings.ReplaceAll(string(data), "* 1.5", "* 1.3")
+	path := filepath.Join(t.TempDir(), "tidb-v1.1.grl")
+	if err := os.WriteFile(path, []byte(candidate), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExecuteWithShadow(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	if err := executor.LoadShadowVersion("1.1.0", &RuleSet{Files: []string{writeCandidateRules(t), "tidb_shard_row_id_bits.table.yaml"}}); err != nil {
+		t.Fatalf("加载候选版本失败: %v", err)
+	}
+
+	// 热点比例约 1.45 倍：生效版本（1.5 倍）不触发，候选版本（1.3 倍）触发
+	monitor := &TiDBMonitor{
+		CheckWriteHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 30.0},
+			{NodeID: "tikv-2", RaftstoreCPU: 32.0},
+			{NodeID: "tikv-3", RaftstoreCPU: 50.0},
+			{NodeID: "tikv-4", RaftstoreCPU: 29.0},
+			{NodeID: "tikv-5", RaftstoreCPU: 31.0},
+		},
+	}
+	monitor.CalculateStatistics()
+
+	result, err := executor.ExecuteWithShadow(monitor)
+	if err != nil {
+		t.Fatalf("影子评估失败: %v", err)
+	}
+	if result.CandidateErr != nil {
+		t.Fatalf("候选版本执行失败: %v", result.CandidateErr)
+	}
+	if monitor.WriteHotspotDetected {
+		t.Errorf("生效版本不应检测到写热点")
+	}
+	if !result.CandidateMonitor.WriteHotspotDetected {
+		t.Errorf("候选版本应检测到写热点")
+	}
+	if len(result.Diffs) != 1 || result.Diffs[0].Kind != FindingAdded || result.Diffs[0].Key != "write_hotspot@tikv-3" {
+		t.Errorf("期望新增 write_hotspot@tikv-3 结论，实际 %v", result.Diffs)
+	}
+}
+
+func TestExecuteWithShadowRequiresCandidate(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	if _, err := executor.ExecuteWithShadow(&TiDBMonitor{}); err == nil {
+		t.Errorf("未加载候选版本时应返回错误")
+	}
+	if err := executor.LoadShadowVersion("1.0.0", &RuleSet{Packs: []string{DefaultRulePack}}); err == nil {
+		t.Errorf("候选版本与生效版本相同时应返回错误")
+	}
+}
+
+func TestExecuteShadowConcurrent(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	if err := executor.LoadShadowVersion("1.1.0", &RuleSet{Files: []string{writeCandidateRules(t)}}); err != nil {
+		t.Fatalf("加载候选版本失败: %v", err)
+	}
+
+	// 并发的候选版本执行各自从实例池取出实例，撤回状态和工作内存互不影响
+	monitors := make([]*TiDBMonitor, 8)
+	errs := make([]error, len(monitors))
+	var wg sync.WaitGroup
+	for i := range monitors {
+		monitors[i] = &TiDBMonitor{
+			CheckWriteHotspot: true,
+			TiKVNodes: []*TiKVNode{
+				{NodeID: "tikv-1", RaftstoreCPU: 30.0},
+				{NodeID: "tikv-2", RaftstoreCPU: 32.0},
+				{NodeID: "tikv-3", RaftstoreCPU: 50.0},
+			},
+		}
+		monitors[i].CalculateStatistics()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = executor.ExecuteShadow(executor.Facts(monitors[i]))
+		}(i)
+	}
+	wg.Wait()
+
+	for i, monitor := range monitors {
+		if errs[i] != nil {
+			t.Fatalf("第 %d 次候选版本执行失败: %v", i, errs[i])
+		}
+		if !monitor.WriteHotspotDetected || monitor.WriteHotspotNode != "tikv-3" {
+			t.Errorf("第 %d 次候选版本应检测到 tikv-3 写热点，实际 %q", i, monitor.WriteHotspotNode)
+		}
+	}
+	if created := executor.shadow.instances.Created(); created < 1 || created > int64(len(monitors)) {
+		t.Errorf("候选版本实例池创建的实例数不符: %d", created)
+	}
+}
+
+func TestExecuteWithShadowCandidateEvaluationError(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	executor.ruleEngine.ReturnErrOnFailedRuleEvaluation = true
+
+	// 候选规则访问不存在的节点，条件求值失败；候选版本需要沿用生效版本的引擎配置返回错误
+	path := filepath.Join(t.TempDir(), "broken.grl")
+	broken := `rule BrokenCandidate "访问越界节点" salience 10 {
+    when
+        TiDBMonitor.TiKVNodes[10].RaftstoreCPU > 0
+    then
+        Retract("BrokenCandidate");
+}`
+	if err := os.WriteFile(path, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := executor.LoadShadowVersion("1.1.0", &RuleSet{Files: []string{path}}); err != nil {
+		t.Fatalf("加载候选版本失败: %v", err)
+	}
+
+	result, err := executor.ExecuteWithShadow(&TiDBMonitor{CheckWriteHotspot: true})
+	if err != nil {
+		t.Fatalf("生效版本执行失败: %v", err)
+	}
+	if result.CandidateErr == nil {
+		t.Errorf("候选版本条件求值失败时应返回错误")
+	}
+	if result.HasDiff() {
+		t.Errorf("候选版本执行失败时不应比较结论，实际 %v", result.Diffs)
+	}
+}
+
+func TestDiffFindings(t *testing.T) {
+	baseline := []Finding{
+		{Type: FindingWriteHotspot, Target: "tikv-3", Value: 2.0},
+		{Type: FindingReadHotspot, Target: "tikv-5", Value: 2.3},
+		{Type: FindingShardRowIDBits, Target: "tikv-3", Value: 8},
+	}
+	candidate := []Finding{
+		{Type: FindingWriteHotspot, Target: "tikv-3", Value: 2.0},
+		{Type: FindingShardRowIDBits, Target: "tikv-3", Value: 10},
+		{Type: FindingReadHotspot, Target: "tikv-4", Value: 1.9},
+	}
+
+	kinds := map[string]string{}
+	for _, diff := range DiffFindings(baseline, candidate) {
+		kinds[diff.Key] = diff.Kind
+	}
+	want := map[string]string{
+		"read_hotspot@tikv-5":      FindingRemoved,
+		"read_hotspot@tikv-4":      FindingAdded,
+		"shard_row_id_bits@tikv-3": FindingChanged,
+	}
+	if len(kinds) != len(want) {
+		t.Fatalf("期望 %v，实际 %v", want, kinds)
+	}
+	for key, kind := range want {
+		if kinds[key] != kind {
+			t.Errorf("%s 期望 %s，实际 %s", key, kind, kinds[key])
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTopDigests 读热点结论附带的 SQL Digest 数量
+const defaultTopDigests = 5
+
+// slowLogMaxLineSize 慢日志单行的最大长度（SQL 文本可能很长）
+const slowLogMaxLineSize = 16 * 1024 * 1024
+
+// SlowQuery TiDB 慢查询日志中的一条记录
+type SlowQuery struct {
+	Time        time.Time
+	DB          string
+	Digest      string
+	Query       string
+	QueryTime   float64 // 秒
+	ProcessTime float64 // 秒
+	ProcessKeys int64
+	TotalKeys   int64
+	NumCopTasks int64
+	CopProcAddr string // 处理时间最长的 cop task 所在的 TiKV 地址，其余 cop task 的地址不在慢日志中
+	IsInternal  bool
+}
+
+// CopProcAddrKeys 估算在 Cop_proc_addr 上处理的 key 数量
+// 慢日志只记录处理时间最长的 cop task 所在的地址，Process_keys 却是所有 cop task 的合计；
+// 多个 cop task 时按平均值把 Process_keys / Num_cop_tasks 计入该地址，其余 cop task 分布在哪些 TiKV 上无法得知
+func (query *SlowQuery) CopProcAddrKeys() int64 {
+	if query.NumCopTasks <= 1 {
+		return query.ProcessKeys
+	}
+	return query.ProcessKeys / query.NumCopTasks
+}
+
+// SlowQueryDigest 按 SQL Digest 聚合的慢查询
+type SlowQueryDigest struct {
+	Digest         string           `json:"digest"`
+	Query          string           `json:"query"` // 样例 SQL（第一次出现的语句）
+	ExecCount      int              `json:"exec_count"`
+	TotalQueryTime float64          `json:"total_query_time"`
+	MaxQueryTime   float64          `json:"max_query_time"`
+	ProcessKeys    int64            `json:"process_keys"`
+	CopTasks       int64            `json:"cop_tasks"`
+	StoreKeys      map[string]int64 `json:"store_keys"`       // Cop_proc_addr -> 估算处理的 key 数量（见 SlowQuery.CopProcAddrKeys）
+	Tables         []string         `json:"tables,omitempty"` // 样例 SQL 中 FROM / JOIN 之后的表，没有库名时加上慢日志中的 DB
+}
+
+// ParseSlowLog 解析 TiDB 慢查询日志，每条记录以 "# Time:" 开始，以 ";" 结尾的 SQL 结束
+func ParseSlowLog(reader io.Reader) ([]*SlowQuery, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), slowLogMaxLineSize)
+
+	var queries []*SlowQuery
+	var current *SlowQuery
+	var sql strings.Builder
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			header := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			if strings.HasPrefix(header, "Time:") {
+				current = &SlowQuery{}
+				sql.Reset()
+			}
+			if current == nil {
+				continue
+			}
+			if err := current.parseHeader(header); err != nil {
+				return nil, fmt.Errorf("慢日志第 %d 行: %v", lineNumber, err)
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		// "use db;" 出现在 SQL 之前，不属于语句本身
+		if sql.Len() == 0 && strings.HasPrefix(strings.ToLower(line), "use ") && strings.HasSuffix(line, ";") {
+			continue
+		}
+		if sql.Len() > 0 {
+			sql.WriteString("\n")
+		}
+		sql.WriteString(line)
+		if strings.HasSuffix(line, ";") {
+			current.Query = sql.String()
+			queries = append(queries, current)
+			current = nil
+			sql.Reset()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取慢日志失败: %v", err)
+	}
+	return queries, nil
+}
+
+// LoadSlowLogFile 解析慢查询日志文件
+func LoadSlowLogFile(path string) ([]*SlowQuery, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseSlowLog(file)
+}
+
+// parseHeader 解析一行 "Key: value Key2: value2" 形式的头部字段，未知字段忽略
+func (query *SlowQuery) parseHeader(header string) error {
+	fields := strings.Fields(header)
+	for i := 0; i+1 < len(fields); i++ {
+		if !strings.HasSuffix(fields[i], ":") {
+			continue
+		}
+		key, value := strings.TrimSuffix(fields[i], ":"), fields[i+1]
+		var err error
+		switch key {
+		case "Time":
+			query.Time, err = time.Parse(time.RFC3339Nano, value)
+		case "DB":
+			query.DB = value
+		case "Digest":
+			query.Digest = value
+		case "Query_time":
+			query.QueryTime, err = strconv.ParseFloat(value, 64)
+		case "Process_time":
+			query.ProcessTime, err = strconv.ParseFloat(value, 64)
+		case "Process_keys":
+			query.ProcessKeys, err = strconv.ParseInt(value, 10, 64)
+		case "Total_keys":
+			query.TotalKeys, err = strconv.ParseInt(value, 10, 64)
+		case "Num_cop_tasks":
+			query.NumCopTasks, err = strconv.ParseInt(value, 10, 64)
+		case "Cop_proc_addr":
+			query.CopProcAddr = value
+		case "Is_internal":
+			query.IsInternal, err = strconv.ParseBool(value)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("字段 %s 的值 %q 无效: %v", key, value, err)
+		}
+		i++
+	}
+	return nil
+}
+
+// AggregateSlowQueries 按 SQL Digest 聚合慢查询（忽略内部 SQL），按处理的 key 数量从多到少排序
+func AggregateSlowQueries(queries []*SlowQuery) []*SlowQueryDigest {
+	byDigest := make(map[string]*SlowQueryDigest)
+	var digests []*SlowQueryDigest
+	for _, query := range queries {
+		if query.IsInternal || query.Digest == "" {
+			continue
+		}
+		digest, ok := byDigest[query.Digest]
+		if !ok {
+			digest = &SlowQueryDigest{Digest: query.Digest, Query: query.Query, StoreKeys: make(map[string]int64), Tables: queryTables(query.DB, query.Query)}
+			byDigest[query.Digest] = digest
+			digests = append(digests, digest)
+		}
+		digest.ExecCount++
+		digest.TotalQueryTime += query.QueryTime
+		if query.QueryTime > digest.MaxQueryTime {
+			digest.MaxQueryTime = query.QueryTime
+		}
+		digest.ProcessKeys += query.ProcessKeys
+		digest.CopTasks += query.NumCopTasks
+		if query.CopProcAddr != "" {
+			digest.StoreKeys[query.CopProcAddr] += query.CopProcAddrKeys()
+		}
+	}
+
+	sort.SliceStable(digests, func(i, j int) bool {
+		return digests[i].ProcessKeys > digests[j].ProcessKeys
+	})
+	return digests
+}
+
+// queryTablePattern 匹配 FROM / JOIN 之后的表名（可以带库名和反引号）
+var queryTablePattern = regexp.MustCompile("(?i)\\b(?:FROM|JOIN)\\s+(`?\\w+`?(?:\\.`?\\w+`?)?)")
+
+// queryTables 提取 SQL 中 FROM / JOIN 之后的表名，只识别每个 FROM / JOIN 后的第一张表（"FROM a, b" 只得到 a），
+// 没有库名时加上 db，格式与 statements_summary 的 TABLE_NAMES 一致（db.table）
+func queryTables(db, sql string) []string {
+	var tables []string
+	for _, match := range queryTablePattern.FindAllStringSubmatch(sql, -1) {
+		table := strings.ReplaceAll(match[1], "`", "")
+		if !strings.Contains(table, ".") && db != "" {
+			table = db + "." + table
+		}
+		if !containsString(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// KeysOn 该 Digest 在指定 TiKV 上估算处理的 key 数量，addresses 为该 TiKV 可能出现在 Cop_proc_addr 中的地址
+func (digest *SlowQueryDigest) KeysOn(addresses ...string) int64 {
+	var keys int64
+	for _, address := range addresses {
+		if address != "" {
+			keys += digest.StoreKeys[address]
+		}
+	}
+	return keys
+}
+
+// TopDigestsForStore 在指定 TiKV 上估算处理 key 最多的 n 个 SQL Digest，addresses 为该 TiKV 可能出现在 Cop_proc_addr 中的地址
+// 只有处理时间最长的 cop task 落在该 TiKV 上的慢查询才会被计入，排序依据是 StoreKeys 中的估算值
+func TopDigestsForStore(digests []*SlowQueryDigest, n int, addresses ...string) []*SlowQueryDigest {
+	var matched []*SlowQueryDigest
+	for _, digest := range digests {
+		if digest.KeysOn(addresses...) > 0 {
+			matched = append(matched, digest)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].KeysOn(addresses...) > matched[j].KeysOn(addresses...)
+	})
+	if n > 0 && len(matched) > n {
+		matched = matched[:n]
+	}
+	return matched
+}
+
+// AttachSlowQueries 将慢查询按 Digest 聚合后挂到监控数据上，CalculateStatistics 会据此计算读热点节点上的 Top SQL
+func (monitor *TiDBMonitor) AttachSlowQueries(queries []*SlowQuery) {
+	monitor.SlowQueryDigests = AggregateSlowQueries(queries)
+}
+
+// calculateReadHotspotDigests 找出读热点候选节点（Coprocessor CPU 最高的节点）上处理 key 最多的 SQL Digest
+func (monitor *TiDBMonitor) calculateReadHotspotDigests() {
+	monitor.ReadHotspotDigests = nil
+	if len(monitor.SlowQueryDigests) == 0 || monitor.ReadHotspotNode == "" {
+		return
+	}
+	monitor.ReadHotspotDigests = TopDigestsForStore(monitor.SlowQueryDigests, defaultTopDigests, monitor.storeAddresses(monitor.ReadHotspotNode)...)
+}
+
+// storeAddresses TiKV 节点可能出现在 Cop_proc_addr 中的地址：节点 ID 和 PD 中登记的地址
+func (monitor *TiDBMonitor) storeAddresses(nodeID string) []string {
+	addresses := []string{nodeID}
+	for _, node := range monitor.TiKVNodes {
+		if node.NodeID == nodeID {
+			addresses = append(addresses, node.Address)
+		}
+	}
+	return addresses
+}
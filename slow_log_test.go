@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testSlowLog = `# Time: 2024-05-20T10:00:01.000000+08:00
+# Txn_start_ts: 449667431212261378
+# User@Host: app[app] @ 10.0.2.15 [10.0.2.15]
+# Conn_ID: 1001
+# Query_time: 1.5
+# Parse_time: 0.0001
+# Process_time: 1.2 Wait_time: 0.01 Request_count: 4 Process_keys: 900000 Total_keys: 900100
+# DB: shop
+# Is_internal: false
+# Digest: digest-orders-scan
+# Num_cop_tasks: 4
+# Cop_proc_avg: 0.3 Cop_proc_p90: 0.5 Cop_proc_max: 0.6 Cop_proc_addr: 10.0.1.5:20160
+# Mem_max: 1024
+# Succ: true
+use shop;
+SELECT * FROM orders
+WHERE status = 'pending';
+# Time: 2024-05-20T10:00:02.000000+08:00
+# Query_time: 2.5
+# Process_time: 2.0 Process_keys: 1200000 Total_keys: 1200000
+# DB: shop
+# Is_internal: false
+# Digest: digest-orders-scan
+# Num_cop_tasks: 6
+# Cop_proc_avg: 0.3 Cop_proc_addr: 10.0.1.5:20160
+SELECT * FROM orders WHERE status = 'paid';
+# Time: 2024-05-20T10:00:03.000000+08:00
+# Query_time: 0.8
+# Process_time: 0.5 Process_keys: 300000 Total_keys: 300000
+# Is_internal: false
+# Digest: digest-users-lookup
+# Num_cop_tasks: 1
+# Cop_proc_addr: 10.0.1.5:20160
+SELECT * FROM users WHERE name LIKE '%a%';
+# Time: 2024-05-20T10:00:04.000000+08:00
+# Query_time: 3.0
+# Process_time: 2.8 Process_keys: 5000000 Total_keys: 5000000
+# Is_internal: false
+# Digest: digest-report
+# Num_cop_tasks: 20
+# Cop_proc_addr: 10.0.1.3:20160
+SELECT COUNT(*) FROM events;
+# Time: 2024-05-20T10:00:05.000000+08:00
+# Query_time: 4.0
+# Process_keys: 8000000
+# Is_internal: true
+# Digest: digest-analyze
+# Cop_proc_addr: 10.0.1.5:20160
+analyze table orders;
+`
+
+func TestParseSlowLog(t *testing.T) {
+	queries, err := ParseSlowLog(strings.NewReader(testSlowLog))
+	if err != nil {
+		t.Fatalf("解析慢日志失败: %v", err)
+	}
+	if len(queries) != 5 {
+		t.Fatalf("期望 5 条慢查询，实际 %d 条", len(queries))
+	}
+
+	first := queries[0]
+	if first.Digest != "digest-orders-scan" || first.DB != "shop" || first.QueryTime != 1.5 ||
+		first.ProcessKeys != 900000 || first.NumCopTasks != 4 || first.CopProcAddr != "10.0.1.5:20160" {
+		t.Errorf("第一条慢查询解析不符: %+v", first)
+	}
+	if first.Query != "SELECT * FROM orders\nWHERE status = 'pending';" {
+		t.Errorf("SQL 解析不符: %q", first.Query)
+	}
+	if first.Time.IsZero() || !queries[4].IsInternal {
+		t.Errorf("时间或内部 SQL 标记解析不符")
+	}
+
+	if _, err := ParseSlowLog(strings.NewReader("# Time: 2024-05-20T10:00:01+08:00\n# Query_time: abc\nselect 1;\n")); err == nil {
+		t.Errorf("无效的 Query_time 应返回错误")
+	}
+}
+
+func TestReadHotspotTopDigests(t *testing.T) {
+	queries, err := ParseSlowLog(strings.NewReader(testSlowLog))
+	if err != nil {
+		t.Fatalf("解析慢日志失败: %v", err)
+	}
+
+	digests := AggregateSlowQueries(queries)
+	if len(digests) != 3 || digests[0].Digest != "digest-report" {
+		t.Fatalf("聚合结果不符（内部 SQL 应被忽略）: %v", digests)
+	}
+	orders := digests[1]
+	if orders.Digest != "digest-orders-scan" || orders.ExecCount != 2 || orders.ProcessKeys != 2100000 ||
+		orders.CopTasks != 10 || orders.MaxQueryTime != 2.5 {
+		t.Errorf("digest-orders-scan 聚合不符: %+v", orders)
+	}
+	// 多个 cop task 的慢查询只把 Process_keys / Num_cop_tasks 计入 Cop_proc_addr：900000/4 + 1200000/6
+	if orders.StoreKeys["10.0.1.5:20160"] != 425000 || digests[0].StoreKeys["10.0.1.3:20160"] != 250000 {
+		t.Errorf("按 cop task 数估算的 TiKV key 数量不符: %v, %v", orders.StoreKeys, digests[0].StoreKeys)
+	}
+
+	executor, err := NewTiDBRuleExecutorWithPack(DefaultRulePack, nil, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+	monitor := &TiDBMonitor{
+		CheckReadHotspot: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", Address: "10.0.1.3:20160", CoprocessorCPU: 25},
+			{NodeID: "tikv-2", Address: "10.0.1.4:20160", CoprocessorCPU: 28},
+			{NodeID: "tikv-3", Address: "10.0.1.5:20160", CoprocessorCPU: 90},
+		},
+	}
+	monitor.AttachSlowQueries(queries)
+	monitor.CalculateStatistics()
+	if err := executor.Execute(monitor); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+
+	findings := monitor.Findings()
+	if len(findings) != 1 || findings[0].Type != FindingReadHotspot {
+		t.Fatalf("期望读热点结论，实际 %v", findings)
+	}
+	top := findings[0].TopDigests
+	if len(top) != 2 || top[0].Digest != "digest-orders-scan" || top[1].Digest != "digest-users-lookup" {
+		t.Errorf("读热点节点上的 Top SQL 不符: %v", top)
+	}
+}
+
+func TestTopDigestsForStoreWeightsCopTasks(t *testing.T) {
+	// digest-wide 的 4000000 个 key 分布在 20 个 cop task 上，只有处理时间最长的一个在 10.0.1.5 上，
+	// 不应排在单个 cop task 全部落在 10.0.1.5 上的 digest-point 之前
+	queries := []*SlowQuery{
+		{Digest: "digest-wide", ProcessKeys: 4000000, NumCopTasks: 20, CopProcAddr: "10.0.1.5:20160"},
+		{Digest: "digest-point", ProcessKeys: 500000, NumCopTasks: 1, CopProcAddr: "10.0.1.5:20160"},
+		{Digest: "digest-unknown", ProcessKeys: 100000, CopProcAddr: "10.0.1.5:20160"},
+	}
+	digests := AggregateSlowQueries(queries)
+	if digests[0].Digest != "digest-wide" {
+		t.Fatalf("整体仍按处理的 key 总数排序，实际 %v", digests)
+	}
+
+	top := TopDigestsForStore(digests, 0, "10.0.1.5:20160")
+	var order []string
+	for _, digest := range top {
+		order = append(order, fmt.Sprintf("%s=%d", digest.Digest, digest.StoreKeys["10.0.1.5:20160"]))
+	}
+	if strings.Join(order, ",") != "digest-point=500000,digest-wide=200000,digest-unknown=100000" {
+		t.Errorf("按 cop task 数估算后的排序不符: %v", order)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StatementSummary INFORMATION_SCHEMA.CLUSTER_STATEMENTS_SUMMARY 中的一行，延迟单位为纳秒
+type StatementSummary struct {
+	Instance           string  `json:"instance,omitempty" yaml:"instance,omitempty"`
+	StmtType           string  `json:"stmt_type" yaml:"stmt_type"` // Insert / Update / Delete / Replace / Select ...
+	SchemaName         string  `json:"schema_name,omitempty" yaml:"schema_name,omitempty"`
+	Digest             string  `json:"digest" yaml:"digest"`
+	DigestText         string  `json:"digest_text,omitempty" yaml:"digest_text,omitempty"`
+	TableNames         string  `json:"table_names,omitempty" yaml:"table_names,omitempty"`
+	ExecCount          int64   `json:"exec_count" yaml:"exec_count"`
+	SumLatency         int64   `json:"sum_latency,omitempty" yaml:"sum_latency,omitempty"`
+	MaxLatency         int64   `json:"max_latency,omitempty" yaml:"max_latency,omitempty"`
+	AvgLatency         int64   `json:"avg_latency,omitempty" yaml:"avg_latency,omitempty"`
+	AvgWriteKeys       float64 `json:"avg_write_keys" yaml:"avg_write_keys"`
+	MaxWriteKeys       int64   `json:"max_write_keys,omitempty" yaml:"max_write_keys,omitempty"`
+	AvgPrewriteRegions float64 `json:"avg_prewrite_regions" yaml:"avg_prewrite_regions"`
+	AvgAffectedRows    float64 `json:"avg_affected_rows,omitempty" yaml:"avg_affected_rows,omitempty"`
+	QuerySampleText    string  `json:"query_sample_text,omitempty" yaml:"query_sample_text,omitempty"`
+}
+
+// IsWrite 是否为写入语句
+func (statement *StatementSummary) IsWrite() bool {
+	switch strings.ToLower(statement.StmtType) {
+	case "insert", "replace", "update", "delete":
+		return true
+	}
+	return false
+}
+
+// stmtTypes 常见语句类型的规范写法（与 TiDB 的 STMT_TYPE 一致），规则按规范写法比较
+var stmtTypes = map[string]string{
+	"insert":  "Insert",
+	"replace": "Replace",
+	"update":  "Update",
+	"delete":  "Delete",
+	"select":  "Select",
+}
+
+// normalizeStmtType 将 INSERT / insert 等写法统一为 Insert，未知类型原样返回
+func normalizeStmtType(stmtType string) string {
+	if normalized, ok := stmtTypes[strings.ToLower(strings.TrimSpace(stmtType))]; ok {
+		return normalized
+	}
+	return stmtType
+}
+
+// TotalWriteKeys 统计周期内写入的 key 总数
+func (statement *StatementSummary) TotalWriteKeys() float64 {
+	return float64(statement.ExecCount) * statement.AvgWriteKeys
+}
+
+// setColumn 按导出列名（大小写不敏感）设置字段，未知列忽略
+func (statement *StatementSummary) setColumn(column, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "NULL") {
+		return nil
+	}
+	var err error
+	switch strings.ToUpper(strings.TrimSpace(column)) {
+	case "INSTANCE":
+		statement.Instance = value
+	case "STMT_TYPE":
+		statement.StmtType = normalizeStmtType(value)
+	case "SCHEMA_NAME":
+		statement.SchemaName = value
+	case "DIGEST":
+		statement.Digest = value
+	case "DIGEST_TEXT":
+		statement.DigestText = value
+	case "TABLE_NAMES":
+		statement.TableNames = value
+	case "EXEC_COUNT":
+		statement.ExecCount, err = parseIntColumn(value)
+	case "SUM_LATENCY":
+		statement.SumLatency, err = parseIntColumn(value)
+	case "MAX_LATENCY":
+		statement.MaxLatency, err = parseIntColumn(value)
+	case "AVG_LATENCY":
+		statement.AvgLatency, err = parseIntColumn(value)
+	case "AVG_WRITE_KEYS":
+		statement.AvgWriteKeys, err = strconv.ParseFloat(value, 64)
+	case "MAX_WRITE_KEYS":
+		statement.MaxWriteKeys, err = parseIntColumn(value)
+	case "AVG_PREWRITE_REGIONS":
+		statement.AvgPrewriteRegions, err = strconv.ParseFloat(value, 64)
+	case "AVG_AFFECTED_ROWS":
+		statement.AvgAffectedRows, err = strconv.ParseFloat(value, 64)
+	case "QUERY_SAMPLE_TEXT":
+		statement.QuerySampleText = value
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("列 %s 的值 %q 无效: %v", column, value, err)
+	}
+	return nil
+}
+
+// parseIntColumn 解析整数列，兼容导出工具把整数写成浮点数的情况
+func parseIntColumn(value string) (int64, error) {
+	if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return parsed, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(parsed), nil
+}
+
+// ParseStatementsSummaryCSV 解析 CSV 导出，第一行为列名（如 DIGEST, STMT_TYPE, EXEC_COUNT, AVG_WRITE_KEYS）
+func ParseStatementsSummaryCSV(reader io.Reader) ([]*StatementSummary, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析 CSV 失败: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	statements := make([]*StatementSummary, 0, len(records)-1)
+	for i, record := range records[1:] {
+		statement := &StatementSummary{}
+		for j, value := range record {
+			if j >= len(header) {
+				break
+			}
+			if err := statement.setColumn(header[j], value); err != nil {
+				return nil, fmt.Errorf("第 %d 行: %v", i+2, err)
+			}
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// ParseStatementsSummaryJSON 解析 JSON 导出：对象数组，键为列名（大小写不敏感）
+func ParseStatementsSummaryJSON(reader io.Reader) ([]*StatementSummary, error) {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	var rows []map[string]interface{}
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("解析 JSON 失败: %v", err)
+	}
+
+	statements := make([]*StatementSummary, 0, len(rows))
+	for i, row := range rows {
+		statement := &StatementSummary{}
+		for column, value := range row {
+			if value == nil {
+				continue
+			}
+			if err := statement.setColumn(column, fmt.Sprint(value)); err != nil {
+				return nil, fmt.Errorf("第 %d 个对象: %v", i+1, err)
+			}
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// LoadStatementsSummaryFile 按扩展名（.csv / .json）加载 statements_summary 导出文件
+func LoadStatementsSummaryFile(path string) ([]*StatementSummary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseStatementsSummaryCSV(bytes.NewReader(data))
+	case ".json":
+		return ParseStatementsSummaryJSON(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("不支持的 statements_summary 文件格式: %s", path)
+	}
+}
+
+// AggregateStatements 将多个 TiDB 实例（CLUSTER_STATEMENTS_SUMMARY）的同一 Digest 合并，按写入 key 总数从多到少排序
+func AggregateStatements(statements []*StatementSummary) []*StatementSummary {
+	byDigest := make(map[string]*StatementSummary)
+	var merged []*StatementSummary
+	for _, statement := range statements {
+		if statement.Digest == "" {
+			continue
+		}
+		current, ok := byDigest[statement.Digest]
+		if !ok {
+			copied := *statement
+			copied.Instance = ""
+			copied.StmtType = normalizeStmtType(copied.StmtType)
+			byDigest[statement.Digest] = &copied
+			merged = append(merged, &copied)
+			continue
+		}
+
+		execCount := current.ExecCount + statement.ExecCount
+		if execCount > 0 {
+			weight := func(a, b float64) float64 {
+				return (a*float64(current.ExecCount) + b*float64(statement.ExecCount)) / float64(execCount)
+			}
+			current.AvgWriteKeys = weight(current.AvgWriteKeys, statement.AvgWriteKeys)
+			current.AvgPrewriteRegions = weight(current.AvgPrewriteRegions, statement.AvgPrewriteRegions)
+			current.AvgAffectedRows = weight(current.AvgAffectedRows, statement.AvgAffectedRows)
+			// CLUSTER_STATEMENTS_SUMMARY 的导出常常只有 AVG_LATENCY，缺少 SUM_LATENCY 时按执行次数加权
+			if current.SumLatency > 0 && statement.SumLatency > 0 {
+				current.AvgLatency = (current.SumLatency + statement.SumLatency) / execCount
+			} else {
+				current.AvgLatency = int64(weight(float64(current.AvgLatency), float64(statement.AvgLatency)))
+			}
+		}
+		current.ExecCount = execCount
+		current.SumLatency += statement.SumLatency
+		if statement.MaxLatency > current.MaxLatency {
+			current.MaxLatency = statement.MaxLatency
+		}
+		if statement.MaxWriteKeys > current.MaxWriteKeys {
+			current.MaxWriteKeys = statement.MaxWriteKeys
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].TotalWriteKeys() > merged[j].TotalWriteKeys()
+	})
+	return merged
+}
+
+// AttachStatements 将 statements_summary 按 Digest 合并后挂到监控数据上，CalculateStatistics 会据此计算写入最多的语句
+func (monitor *TiDBMonitor) AttachStatements(statements []*StatementSummary) {
+	monitor.Statements = AggregateStatements(statements)
+}
+
+// calculateWorkloadStatistics 找出写入 key 最多的写语句及其占所有写入的比例
+func (monitor *TiDBMonitor) calculateWorkloadStatistics() {
+	monitor.TopWriteDigest = ""
+	monitor.TopWriteStmtType = ""
+	monitor.TopWriteTables = ""
+	monitor.TopWriteExecCount = 0
+	monitor.TopWriteAvgWriteKeys = 0
+	monitor.TopWriteAvgPrewriteRegions = 0
+	monitor.TopWriteKeysShare = 0
+
+	var top *StatementSummary
+	var totalWriteKeys float64
+	for _, statement := range monitor.Statements {
+		if !statement.IsWrite() {
+			continue
+		}
+		totalWriteKeys += statement.TotalWriteKeys()
+		if top == nil || statement.TotalWriteKeys() > top.TotalWriteKeys() {
+			top = statement
+		}
+	}
+	if top == nil || totalWriteKeys == 0 {
+		return
+	}
+
+	monitor.TopWriteDigest = top.Digest
+	monitor.TopWriteStmtType = top.StmtType
+	monitor.TopWriteTables = top.TableNames
+	monitor.TopWriteExecCount = top.ExecCount
+	monitor.TopWriteAvgWriteKeys = top.AvgWriteKeys
+	monitor.TopWriteAvgPrewriteRegions = top.AvgPrewriteRegions
+	monitor.TopWriteKeysShare = top.TotalWriteKeys() / totalWriteKeys
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testStatementsCSV = `INSTANCE,STMT_TYPE,SCHEMA_NAME,DIGEST,DIGEST_TEXT,TABLE_NAMES,EXEC_COUNT,SUM_LATENCY,MAX_LATENCY,AVG_LATENCY,AVG_WRITE_KEYS,MAX_WRITE_KEYS,AVG_PREWRITE_REGIONS
+tidb-1:4000,Insert,app,d-insert,"insert into access_log values ( ... )",app.access_log,300000,600000000,5000000,2000,2,2,2.5
+tidb-2:4000,Insert,app,d-insert,"insert into access_log values ( ... )",app.access_log,100000,400000000,9000000,4000,2,3,1.5
+tidb-1:4000,Select,app,d-select,select * from users where id = ?,app.users,900000,900000000,1000000,1000,NULL,NULL,NULL
+tidb-1:4000,Update,app,d-update,update users set name = ? where id = ?,app.users,50000,100000000,2000000,2000,4,4,1
+`
+
+func TestParseStatementsSummary(t *testing.T) {
+	statements, err := ParseStatementsSummaryCSV(strings.NewReader(testStatementsCSV))
+	if err != nil {
+		t.Fatalf("解析 CSV 失败: %v", err)
+	}
+	if len(statements) != 4 {
+		t.Fatalf("期望 4 行，实际 %d 行", len(statements))
+	}
+	first := statements[0]
+	if first.Instance != "tidb-1:4000" || first.StmtType != "Insert" || first.ExecCount != 300000 ||
+		first.AvgWriteKeys != 2 || first.AvgPrewriteRegions != 2.5 || first.DigestText != "insert into access_log values ( ... )" {
+		t.Errorf("第一行解析不符: %+v", first)
+	}
+	if statements[2].IsWrite() || statements[2].AvgWriteKeys != 0 {
+		t.Errorf("SELECT 不应视为写语句，NULL 应被忽略: %+v", statements[2])
+	}
+
+	jsonRows := `[{"digest": "d-insert", "stmt_type": "Insert", "exec_count": 12000, "avg_write_keys": 1.0, "AVG_PREWRITE_REGIONS": 1, "table_names": null}]`
+	statements, err = ParseStatementsSummaryJSON(strings.NewReader(jsonRows))
+	if err != nil {
+		t.Fatalf("解析 JSON 失败: %v", err)
+	}
+	if len(statements) != 1 || statements[0].ExecCount != 12000 || statements[0].AvgPrewriteRegions != 1 || !statements[0].IsWrite() {
+		t.Errorf("JSON 解析不符: %+v", statements)
+	}
+
+	if _, err := ParseStatementsSummaryCSV(strings.NewReader("DIGEST,EXEC_COUNT\nd-1,abc\n")); err == nil {
+		t.Errorf("无效的 EXEC_COUNT 应返回错误")
+	}
+}
+
+func TestWorkloadStatistics(t *testing.T) {
+	statements, err := ParseStatementsSummaryCSV(strings.NewReader(testStatementsCSV))
+	if err != nil {
+		t.Fatalf("解析 CSV 失败: %v", err)
+	}
+
+	merged := AggregateStatements(statements)
+	if len(merged) != 3 || merged[0].Digest != "d-insert" {
+		t.Fatalf("合并结果不符: %v", merged)
+	}
+	insert := merged[0]
+	if insert.ExecCount != 400000 || insert.AvgPrewriteRegions != 2.25 || insert.SumLatency != 1000000000 ||
+		insert.AvgLatency != 2500 || insert.MaxLatency != 9000000 || insert.MaxWriteKeys != 3 || insert.Instance != "" {
+		t.Errorf("d-insert 合并不符: %+v", insert)
+	}
+	if statements[0].ExecCount != 300000 {
+		t.Errorf("合并不应修改原始数据")
+	}
+
+	// 每个实例一行且没有 SUM_LATENCY 时，AVG_LATENCY 按执行次数加权；STMT_TYPE 的大小写统一
+	perInstance, err := ParseStatementsSummaryCSV(strings.NewReader(`INSTANCE,STMT_TYPE,DIGEST,EXEC_COUNT,AVG_LATENCY,AVG_WRITE_KEYS
+tidb-1:4000,INSERT,d-insert,300000,2000,1
+tidb-2:4000,insert,d-insert,100000,4000,1
+`))
+	if err != nil {
+		t.Fatalf("解析 CSV 失败: %v", err)
+	}
+	merged = AggregateStatements(perInstance)
+	if len(merged) != 1 || merged[0].AvgLatency != 2500 || merged[0].StmtType != "Insert" || !merged[0].IsWrite() {
+		t.Errorf("缺少 SUM_LATENCY 的合并不符: %+v", merged[0])
+	}
+
+	monitor := &TiDBMonitor{}
+	monitor.AttachStatements(statements)
+	monitor.CalculateStatistics()
+	if monitor.TopWriteDigest != "d-insert" || monitor.TopWriteStmtType != "Insert" ||
+		monitor.TopWriteTables != "app.access_log" || monitor.TopWriteExecCount != 400000 {
+		t.Errorf("写入最多的语句不符: %+v", monitor)
+	}
+	if share := monitor.TopWriteKeysShare; share < 0.79 || share > 0.81 {
+		t.Errorf("写入占比应为 0.8，实际 %.3f", share)
+	}
+}
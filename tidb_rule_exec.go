@@ -3,18 +3,34 @@ package main
 import (
 	"fmt"
 	"log"
-
-	"github.com/hyperjumptech/grule-rule-engine/ast"
-	"github.com/hyperjumptech/grule-rule-engine/builder"
-	"github.com/hyperjumptech/grule-rule-engine/engine"
-	"github.com/hyperjumptech/grule-rule-engine/pkg"
 )
 
 // TiKVNode TiKV 节点信息
 type TiKVNode struct {
-	NodeID         string
-	RaftstoreCPU   float64
-	CoprocessorCPU float64
+	NodeID         string  `json:"node_id" yaml:"node_id"`
+	RaftstoreCPU   float64 `json:"raftstore_cpu" yaml:"raftstore_cpu"`
+	CoprocessorCPU float64 `json:"coprocessor_cpu" yaml:"coprocessor_cpu"`
+	Address        string  `json:"address,omitempty" yaml:"address,omitempty"` // TiKV 服务地址（host:port），用于匹配慢日志中的 Cop_proc_addr
+
+	CPUCores int `json:"cpu_cores,omitempty" yaml:"cpu_cores,omitempty"` // 节点 CPU 核数，用于限制线程池的有效线程数及计算默认线程池大小
+
+	// PD store labels，如 zone / rack / host，用于按拓扑分组统计
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+
+	// 其他线程池 CPU（%，100% 为一个核）
+	SchedulerWorkerCPU float64 `json:"scheduler_worker_cpu,omitempty" yaml:"scheduler_worker_cpu,omitempty"`
+	ApplyCPU           float64 `json:"apply_cpu,omitempty" yaml:"apply_cpu,omitempty"`
+	GRPCPollCPU        float64 `json:"grpc_poll_cpu,omitempty" yaml:"grpc_poll_cpu,omitempty"`
+	UnifiedReadPoolCPU float64 `json:"unified_read_pool_cpu,omitempty" yaml:"unified_read_pool_cpu,omitempty"`
+	StorageReadPoolCPU float64 `json:"storage_read_pool_cpu,omitempty" yaml:"storage_read_pool_cpu,omitempty"`
+
+	// 线程池大小配置，为 0 时按 TiKV 默认值计算
+	StorePoolSize           int `json:"store_pool_size,omitempty" yaml:"store_pool_size,omitempty"`                       // raftstore.store-pool-size
+	ApplyPoolSize           int `json:"apply_pool_size,omitempty" yaml:"apply_pool_size,omitempty"`                       // raftstore.apply-pool-size
+	SchedulerWorkerPoolSize int `json:"scheduler_worker_pool_size,omitempty" yaml:"scheduler_worker_pool_size,omitempty"` // storage.scheduler-worker-pool-size
+	GRPCConcurrency         int `json:"grpc_concurrency,omitempty" yaml:"grpc_concurrency,omitempty"`                     // server.grpc-concurrency
+	UnifiedReadPoolSize     int `json:"unified_read_pool_size,omitempty" yaml:"unified_read_pool_size,omitempty"`         // readpool.unified.max-thread-count
+	StorageReadPoolSize     int `json:"storage_read_pool_size,omitempty" yaml:"storage_read_pool_size,omitempty"`         // readpool.storage.normal-concurrency
 }
 
 // TiDBMonitor TiDB 监控数据结构
@@ -23,6 +39,13 @@ type TiDBMonitor struct {
 	CheckWriteHotspot bool
 	CheckReadHotspot  bool
 
+	// 规则消息的语言（zh-CN / en-US），为空时使用进程级默认语言，见 TiDBMonitor.Message
+	Locale   string
+	Messages []RuleMessage // 规则输出的消息
+
+	// 节点级规则的结果，由 TiKVNodeRuleExecutor.EvaluateNodes 填充
+	NodeResults []*NodeResult
+
 	// TiKV 节点列表
 	TiKVNodes []*TiKVNode
 
@@ -36,16 +59,113 @@ type TiDBMonitor struct {
 	AvgCoprocessorCPU float64
 	ReadHotspotNode   string
 
+	// 按容量归一化的利用率（CPU / (线程池大小 × 100%)，线程池大小不超过 CPU 核数，需要在 Go 代码中计算）
+	NormalizeCPU               bool    // 为 true 时热点统计（MaxRaftstoreCPU、AvgRaftstoreCPU 等）使用利用率（%）代替原始 CPU
+	HeterogeneousHardware      bool    // 节点的 CPU 核数或线程池大小不一致
+	MaxRaftstoreUtilization    float64 // 0-1
+	AvgRaftstoreUtilization    float64
+	RaftstoreUtilizationNode   string
+	MaxCoprocessorUtilization  float64
+	AvgCoprocessorUtilization  float64
+	CoprocessorUtilizationNode string
+
+	// 拓扑统计信息（按 store label 分组，需要在 Go 代码中计算）
+	CheckTopology                    bool
+	Zones                            []*TopologyGroup
+	Hosts                            []*TopologyGroup
+	ZoneCount                        int
+	HotWriteZone                     string  // Raftstore CPU 平均值最高的 zone
+	HotWriteZoneNodeCount            int     // 该 zone 的节点数
+	HotWriteZoneAvgRaftstoreCPU      float64 // 该 zone 的 Raftstore CPU 平均值
+	HotWriteZoneMinRaftstoreCPU      float64 // 该 zone 的 Raftstore CPU 最小值
+	OtherZonesAvgRaftstoreCPU        float64 // 其他 zone 节点的 Raftstore CPU 平均值
+	HotReadZone                      string  // Coprocessor CPU 平均值最高的 zone
+	HotReadZoneNodeCount             int
+	HotReadZoneAvgCoprocessorCPU     float64
+	HotReadZoneMinCoprocessorCPU     float64
+	OtherZonesAvgCoprocessorCPU      float64
+	WriteHotspotZone                 string  // 写热点节点所在的 zone
+	WriteHotspotZonePeerRaftstoreCPU float64 // 同 zone 其他节点的 Raftstore CPU 平均值
+	WriteHotspotHost                 string  // 写热点节点所在的主机
+	WriteHotspotHostStoreCount       int     // 该主机上的 TiKV 实例数
+
 	// 检测结果
 	WriteHotspotDetected bool
 	WriteHotspotRatio    float64
 	ReadHotspotDetected  bool
 	ReadHotspotRatio     float64
 
+	// 拓扑检测结果
+	ZoneWriteImbalance        bool    // 整个 zone 的 Raftstore CPU 偏高（放置规则或 leader 偏好导致）
+	ZoneWriteImbalanceRatio   float64 // 热点 zone 的 Raftstore CPU 平均值 / 其他 zone 平均值
+	ZoneReadImbalance         bool    // 整个 zone 的 Coprocessor CPU 偏高
+	ZoneReadImbalanceRatio    float64 // 热点 zone 的 Coprocessor CPU 平均值 / 其他 zone 平均值
+	IsolatedWriteHotspot      bool    // 写热点只出现在单个 store 上，同 zone 其他节点正常
+	IsolatedWriteHotspotRatio float64 // 写热点节点 Raftstore CPU / 同 zone 其他节点平均值
+	SharedHostWriteHotspot    bool    // 写热点节点所在主机上还部署了其他 TiKV 实例
+
 	// 非聚簇索引热点相关
 	IsNonClusteredIndexHotspot bool // 是否是非聚簇索引导致的写热点
 	ShardRowIDBits             int  // 建议的 SHARD_ROW_ID_BITS 值（0-15）
 	RecommendShardRowIDBits    bool // 是否建议设置 SHARD_ROW_ID_BITS
+
+	// TiDB Server（SQL 层）节点列表
+	CheckTiDBServer bool
+	TiDBServers     []*TiDBServerNode
+
+	// TiDB Server 统计信息（需要在 Go 代码中计算）
+	TiDBServerCount          int
+	MaxTiDBServerConnections float64
+	AvgTiDBServerConnections float64
+	TiDBServerConnectionNode string
+	MaxTiDBServerQPS         float64
+	AvgTiDBServerQPS         float64
+	TiDBServerQPSNode        string
+	MaxTiDBServerCPU         float64
+	AvgTiDBServerCPU         float64
+	TiDBServerCPUNode        string
+	MaxTiDBServerMemoryUsage float64
+	TiDBServerMemoryNode     string
+	MaxTiDBServerDurationP99 float64
+	TiDBServerDurationNode   string // 查询耗时 P99 最高的节点
+
+	// TiDB Server 检测结果
+	TiDBServerSkewDetected   bool    // 负载均衡不均（连接数、QPS 或 CPU）
+	TiDBServerSkewNode       string  // 负载最高的节点
+	TiDBServerSkewRatio      float64 // 最高值 / 平均值
+	TiDBServerMemoryPressure bool    // 内存使用率 >= 80%
+	TiDBServerOOMRisk        bool    // 内存使用率 >= 90%
+	TiDBServerHighLatency    bool    // 查询耗时 P99 >= 1000 毫秒
+
+	// TiKV 线程池饱和度（由 CalculateStatistics 创建，Saturated 由规则设置）
+	CheckTiKVThreadPools bool
+	RaftstorePool        *ThreadPoolStats
+	ApplyPool            *ThreadPoolStats
+	SchedulerWorkerPool  *ThreadPoolStats
+	GRPCPool             *ThreadPoolStats
+	UnifiedReadPool      *ThreadPoolStats
+	StorageReadPool      *ThreadPoolStats
+
+	// 慢查询（按 SQL Digest 聚合，见 AttachSlowQueries）及读热点节点上处理 key 最多的 SQL
+	SlowQueryDigests   []*SlowQueryDigest
+	ReadHotspotDigests []*SlowQueryDigest
+
+	// 负载画像（statements_summary，见 AttachStatements）
+	CheckWorkload bool
+	Statements    []*StatementSummary
+
+	// 写入 key 最多的写语句（需要在 Go 代码中计算）
+	TopWriteDigest             string
+	TopWriteStmtType           string
+	TopWriteTables             string
+	TopWriteExecCount          int64
+	TopWriteAvgWriteKeys       float64
+	TopWriteAvgPrewriteRegions float64
+	TopWriteKeysShare          float64 // 占所有写语句写入 key 总数的比例
+
+	// 负载相关建议
+	RecommendInsertBatching   bool
+	RecommendWriteKeyRedesign bool
 }
 
 // CalculateStatistics 计算统计信息（平均值、最大值等）
@@ -61,16 +181,18 @@ func (monitor *TiDBMonitor) CalculateStatistics() {
 
 		for _, node := range monitor.TiKVNodes {
 			// 写热点统计
-			totalRaftstoreCPU += node.RaftstoreCPU
-			if node.RaftstoreCPU > maxRaftstoreCPU {
-				maxRaftstoreCPU = node.RaftstoreCPU
+			raftstoreCPU := monitor.raftstoreLoad(node)
+			totalRaftstoreCPU += raftstoreCPU
+			if raftstoreCPU > maxRaftstoreCPU {
+				maxRaftstoreCPU = raftstoreCPU
 				writeHotspotNode = node.NodeID
 			}
 
 			// 读热点统计
-			totalCoprocessorCPU += node.CoprocessorCPU
-			if node.CoprocessorCPU > maxCoprocessorCPU {
-				maxCoprocessorCPU = node.CoprocessorCPU
+			coprocessorCPU := monitor.coprocessorLoad(node)
+			totalCoprocessorCPU += coprocessorCPU
+			if coprocessorCPU > maxCoprocessorCPU {
+				maxCoprocessorCPU = coprocessorCPU
 				readHotspotNode = node.NodeID
 			}
 		}
@@ -83,15 +205,68 @@ func (monitor *TiDBMonitor) CalculateStatistics() {
 		monitor.AvgCoprocessorCPU = totalCoprocessorCPU / float64(len(monitor.TiKVNodes))
 		monitor.ReadHotspotNode = readHotspotNode
 	}
+
+	// 按容量归一化的利用率
+	monitor.calculateCapacityStatistics()
+
+	// 按 zone / host 计算拓扑统计信息
+	monitor.calculateTopologyStatistics()
+
+	// 计算 TiDB Server 统计信息
+	monitor.calculateTiDBServerStatistics()
+
+	// 计算 TiKV 线程池统计信息
+	monitor.calculateThreadPoolStatistics()
+
+	// 读热点候选节点上的 Top SQL
+	monitor.calculateReadHotspotDigests()
+
+	// 写入最多的语句
+	monitor.calculateWorkloadStatistics()
+}
+
+// tidbExecutorFacts TiDB 规则执行器向数据上下文提供的事实
+var tidbExecutorFacts = []string{"TiDBMonitor"}
+
+// Clone 深拷贝监控数据，用于在不影响原数据的情况下执行其他规则版本
+func (monitor *TiDBMonitor) Clone() *TiDBMonitor {
+	cloned := *monitor
+	cloned.TiKVNodes = make([]*TiKVNode, 0, len(monitor.TiKVNodes))
+	for _, node := range monitor.TiKVNodes {
+		copied := *node
+		if node.Labels != nil {
+			copied.Labels = make(map[string]string, len(node.Labels))
+			for key, value := range node.Labels {
+				copied.Labels[key] = value
+			}
+		}
+		cloned.TiKVNodes = append(cloned.TiKVNodes, &copied)
+	}
+	if monitor.TiDBServers != nil {
+		cloned.TiDBServers = make([]*TiDBServerNode, 0, len(monitor.TiDBServers))
+		for _, node := range monitor.TiDBServers {
+			copied := *node
+			cloned.TiDBServers = append(cloned.TiDBServers, &copied)
+		}
+	}
+	monitor.cloneThreadPools(&cloned)
+	cloned.Messages = append([]RuleMessage(nil), monitor.Messages...)
+	if monitor.NodeResults != nil {
+		cloned.NodeResults = make([]*NodeResult, 0, len(monitor.NodeResults))
+		for _, result := range monitor.NodeResults {
+			cloned.NodeResults = append(cloned.NodeResults, result.clone())
+		}
+	}
+	// 拓扑分组、慢查询和 statements_summary 聚合结果只读，副本之间共享
+	return &cloned
 }
 
-// TiDBRuleExecutor TiDB 规则执行器
+// tidbMonitorFact TiDBMonitor 在数据上下文中的名称
+const tidbMonitorFact = "TiDBMonitor"
+
+// TiDBRuleExecutor TiDB 规则执行器，以 TiDBMonitor 为事实的通用规则执行器
 type TiDBRuleExecutor struct {
-	knowledgeLibrary *ast.KnowledgeLibrary
-	knowledgeBase    *ast.KnowledgeBase
-	ruleEngine       *engine.GruleEngine
-	ruleName         string
-	ruleVersion      string
+	*FactExecutor[TiDBMonitor]
 }
 
 // NewTiDBRuleExecutor 创建并初始化 TiDB 规则执行器（支持单个规则文件）
@@ -105,58 +280,33 @@ func NewTiDBRuleExecutorWithFiles(ruleFiles []string, ruleName, ruleVersion stri
 	if len(ruleFiles) == 0 {
 		return nil, fmt.Errorf("至少需要提供一个规则文件")
 	}
-
-	// 1. 创建知识库
-	knowledgeLibrary := ast.NewKnowledgeLibrary()
-	ruleBuilder := builder.NewRuleBuilder(knowledgeLibrary)
-
-	// 2. 加载所有规则文件到同一个知识库
-	for i, ruleFile := range ruleFiles {
-		err := ruleBuilder.BuildRuleFromResource(ruleName, ruleVersion, pkg.NewFileResource(ruleFile))
-		if err != nil {
-			return nil, fmt.Errorf("加载规则文件 [%d/%d] %s 失败: %v", i+1, len(ruleFiles), ruleFile, err)
-		}
-	}
-
-	// 3. 获取知识库实例
-	knowledgeBase := knowledgeLibrary.NewKnowledgeBaseInstance(ruleName, ruleVersion)
-
-	// 4. 创建规则引擎
-	ruleEngine := engine.NewGruleEngine()
-
-	return &TiDBRuleExecutor{
-		knowledgeLibrary: knowledgeLibrary,
-		knowledgeBase:    knowledgeBase,
-		ruleEngine:       ruleEngine,
-		ruleName:         ruleName,
-		ruleVersion:      ruleVersion,
-	}, nil
+	return NewTiDBRuleExecutorWithRuleSet(&RuleSet{Files: ruleFiles}, ruleName, ruleVersion)
 }
 
-// Execute 执行规则引擎
-func (executor *TiDBRuleExecutor) Execute(monitor *TiDBMonitor) error {
-	// 创建数据上下文
-	dataContext := ast.NewDataContext()
-	err := dataContext.Add("TiDBMonitor", monitor)
-	if err != nil {
-		return fmt.Errorf("添加 TiDBMonitor 到数据上下文失败: %v", err)
-	}
+// NewTiDBRuleExecutorWithPack 使用规则包（及其依赖）创建 TiDB 规则执行器，extraRuleFiles 为额外加载的磁盘规则文件
+func NewTiDBRuleExecutorWithPack(packName string, extraRuleFiles []string, ruleName, ruleVersion string) (*TiDBRuleExecutor, error) {
+	return NewTiDBRuleExecutorWithRuleSet(&RuleSet{Packs: []string{packName}, Files: extraRuleFiles}, ruleName, ruleVersion)
+}
 
-	// 执行规则
-	err = executor.ruleEngine.Execute(dataContext, executor.knowledgeBase)
+// NewTiDBRuleExecutorWithRuleSet 创建并初始化 TiDB 规则执行器，规则集合中的所有规则会被加载到同一个知识库中
+func NewTiDBRuleExecutorWithRuleSet(ruleSet *RuleSet, ruleName, ruleVersion string) (*TiDBRuleExecutor, error) {
+	executor, err := NewFactExecutor[TiDBMonitor](ruleSet, ruleName, ruleVersion, tidbMonitorFact)
 	if err != nil {
-		return fmt.Errorf("执行规则失败: %v", err)
+		return nil, err
 	}
-
-	return nil
+	// 规则中可以通过 Stats 对 TiKVNodes 做聚合查询，见 NodeStats
+	executor.AddDerivedFact(statsFact, func(facts Facts) interface{} {
+		return NewNodeStats(facts[tidbMonitorFact].(*TiDBMonitor))
+	})
+	return &TiDBRuleExecutor{FactExecutor: executor}, nil
 }
 
 // ExecuteWithLog 执行规则引擎并输出日志
 func (executor *TiDBRuleExecutor) ExecuteWithLog(monitor *TiDBMonitor) error {
-	fmt.Println("\n执行规则引擎...")
+	fmt.Println(T("main.executing"))
 	err := executor.Execute(monitor)
 	if err != nil {
-		log.Fatalf("执行规则失败: %v", err)
+		log.Fatal(T("main.execute_failed", err))
 	}
 	return err
 }
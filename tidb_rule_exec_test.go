@@ -3,15 +3,18 @@ package main
 import (
 	"fmt"
 	"log"
+	"reflect"
 	"testing"
 )
 
+// tidbHotspotRuleFiles 磁盘上的 tidb-hotspot 规则：tidb.grl 及编译为 GRL 的 SHARD_ROW_ID_BITS 决策表
+var tidbHotspotRuleFiles = []string{"tidb.grl", "tidb_shard_row_id_bits.table.yaml"}
+
 // 1. 演示写热点检测
 func TestDetectReadHotspot(t *testing.T) {
 
-	// 1. 初始化规则执行器（单个规则文件）
-	ruleFile := "tidb.grl"
-	ruleExecutor, err := NewTiDBRuleExecutor(ruleFile, "TiDBHotspot", "1.0.0")
+	// 1. 初始化规则执行器（热点规则文件及 SHARD_ROW_ID_BITS 决策表）
+	ruleExecutor, err := NewTiDBRuleExecutorWithFiles(tidbHotspotRuleFiles, "TiDBHotspot", "1.0.0")
 	if err != nil {
 		t.Fatalf("初始化规则执行器失败: %v", err)
 	}
@@ -94,8 +97,7 @@ func TestDetectReadHotspot(t *testing.T) {
 // 6. 演示非聚簇索引写入热点
 func TestDetectNonClusteredIndexWriteHotspot(t *testing.T) {
 
-	ruleFile := "tidb.grl"
-	ruleExecutor, err := NewTiDBRuleExecutor(ruleFile, "TiDBHotspot", "1.0.0")
+	ruleExecutor, err := NewTiDBRuleExecutorWithFiles(tidbHotspotRuleFiles, "TiDBHotspot", "1.0.0")
 	if err != nil {
 		t.Fatalf("初始化规则执行器失败: %v", err)
 	}
@@ -143,9 +145,8 @@ func TestDetectNonClusteredIndexWriteHotspot(t *testing.T) {
 
 // 7. 演示规则不匹配的情况
 func TestWriteHotspotNotMatch(t *testing.T) {
-	// 1. 初始化规则执行器（单个规则文件）
-	ruleFile := "tidb.grl"
-	ruleExecutor, err := NewTiDBRuleExecutor(ruleFile, "TiDBHotspot", "1.0.0")
+	// 1. 初始化规则执行器（热点规则文件及 SHARD_ROW_ID_BITS 决策表）
+	ruleExecutor, err := NewTiDBRuleExecutorWithFiles(tidbHotspotRuleFiles, "TiDBHotspot", "1.0.0")
 	if err != nil {
 		t.Fatalf("初始化规则执行器失败: %v", err)
 	}
@@ -198,9 +199,8 @@ func TestWriteHotspotNotMatch(t *testing.T) {
 
 // 6. 演示非聚簇索引建议规则不匹配 - 检测到写热点但不是非聚簇索引热点
 func TestNonClusteredIndexWriteHotspotNotMatch(t *testing.T) {
-	// 1. 初始化规则执行器（单个规则文件）
-	ruleFile := "tidb.grl"
-	ruleExecutor, err := NewTiDBRuleExecutor(ruleFile, "TiDBHotspot", "1.0.0")
+	// 1. 初始化规则执行器（热点规则文件及 SHARD_ROW_ID_BITS 决策表）
+	ruleExecutor, err := NewTiDBRuleExecutorWithFiles(tidbHotspotRuleFiles, "TiDBHotspot", "1.0.0")
 	if err != nil {
 		t.Fatalf("初始化规则执行器失败: %v", err)
 	}
@@ -249,9 +249,8 @@ func TestNonClusteredIndexWriteHotspotNotMatch(t *testing.T) {
 
 // 7. 演示非聚簇索引建议规则不匹配 - 热点比例不在建议范围内
 func TestNonClusteredIndexWriteHotspotNotMatchRatio(t *testing.T) {
-	// 1. 初始化规则执行器（单个规则文件）
-	ruleFile := "tidb.grl"
-	ruleExecutor, err := NewTiDBRuleExecutor(ruleFile, "TiDBHotspot", "1.0.0")
+	// 1. 初始化规则执行器（热点规则文件及 SHARD_ROW_ID_BITS 决策表）
+	ruleExecutor, err := NewTiDBRuleExecutorWithFiles(tidbHotspotRuleFiles, "TiDBHotspot", "1.0.0")
 	if err != nil {
 		t.Fatalf("初始化规则执行器失败: %v", err)
 	}
@@ -302,9 +301,8 @@ func TestNonClusteredIndexWriteHotspotNotMatchRatio(t *testing.T) {
 
 // 8. 演示正常情况
 func TestNormalCase(t *testing.T) {
-	// 1. 初始化规则执行器（单个规则文件）
-	ruleFile := "tidb.grl"
-	ruleExecutor, err := NewTiDBRuleExecutor(ruleFile, "TiDBHotspot", "1.0.0")
+	// 1. 初始化规则执行器（热点规则文件及 SHARD_ROW_ID_BITS 决策表）
+	ruleExecutor, err := NewTiDBRuleExecutorWithFiles(tidbHotspotRuleFiles, "TiDBHotspot", "1.0.0")
 	if err != nil {
 		t.Fatalf("初始化规则执行器失败: %v", err)
 	}
@@ -348,3 +346,69 @@ func TestNormalCase(t *testing.T) {
 		fmt.Printf("  ✗ 未检测到读热点（正常）\n")
 	}
 }
+
+// 同一个执行器依次执行多个不同的监控数据（与 main.go 的用法相同），每次执行的结果应与使用新执行器一致
+func TestSequentialMonitorsOnOneExecutor(t *testing.T) {
+	newMonitors := func() []*TiDBMonitor {
+		cpus := [][]float64{
+			{30.5, 32.1, 85.2, 29.8, 31.2}, // 写热点，比例约 2.28 倍
+			{25.3, 28.7, 95.8, 26.2, 27.5}, // 写热点，比例约 2.36 倍
+			{30.0, 32.0, 45.0, 29.0, 31.0}, // 比例不足 1.5 倍
+			{30.0, 32.0, 85.0, 29.0, 31.0}, // 写热点，不是非聚簇索引
+			{10.0, 10.0, 90.0, 10.0, 10.0}, // 写热点，比例 3.46 倍
+			{30.5, 32.1, 31.8, 29.8, 31.2}, // 正常
+		}
+		nonClustered := []bool{false, true, true, false, true, false}
+		var monitors []*TiDBMonitor
+		for i, row := range cpus {
+			monitor := &TiDBMonitor{CheckWriteHotspot: true, CheckReadHotspot: true, IsNonClusteredIndexHotspot: nonClustered[i]}
+			for j, cpu := range row {
+				monitor.TiKVNodes = append(monitor.TiKVNodes, &TiKVNode{NodeID: fmt.Sprintf("tikv-%d", j+1), RaftstoreCPU: cpu, CoprocessorCPU: 25})
+			}
+			monitor.CalculateStatistics()
+			monitors = append(monitors, monitor)
+		}
+		return monitors
+	}
+
+	executor, err := NewTiDBRuleExecutorWithFiles(tidbHotspotRuleFiles, "TiDBHotspot", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+
+	// 执行两轮，第二轮的每个监控数据都在上一轮撤回过规则的执行器上执行
+	for round := 1; round <= 2; round++ {
+		for i, monitor := range newMonitors() {
+			firedRules, err := executor.ExecuteWithTrace(monitor)
+			if err != nil {
+				t.Fatalf("第 %d 轮第 %d 个监控数据执行失败: %v", round, i+1, err)
+			}
+
+			fresh, err := NewTiDBRuleExecutorWithFiles(tidbHotspotRuleFiles, "TiDBHotspot", "1.0.0")
+			if err != nil {
+				t.Fatalf("初始化规则执行器失败: %v", err)
+			}
+			expected := newMonitors()[i]
+			expectedRules, err := fresh.ExecuteWithTrace(expected)
+			if err != nil {
+				t.Fatalf("新执行器执行失败: %v", err)
+			}
+
+			if !reflect.DeepEqual(sortedRules(firedRules), sortedRules(expectedRules)) {
+				t.Errorf("第 %d 轮第 %d 个监控数据: 触发规则 %v，新执行器触发 %v", round, i+1, firedRules, expectedRules)
+			}
+			if !reflect.DeepEqual(monitor.Findings(), expected.Findings()) {
+				t.Errorf("第 %d 轮第 %d 个监控数据: 结论 %v，新执行器结论 %v", round, i+1, monitor.Findings(), expected.Findings())
+			}
+		}
+	}
+
+	// 实例池中的实例归还后不应保留撤回状态
+	knowledgeBase := executor.InstancePool().Get()
+	defer executor.InstancePool().Put(knowledgeBase)
+	for _, entry := range knowledgeBase.RuleEntries {
+		if entry.Retracted {
+			t.Errorf("规则 %s 在执行结束后仍处于撤回状态", entry.RuleName)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+// TiDBServerNode TiDB Server（SQL 层）节点信息
+type TiDBServerNode struct {
+	NodeID          string  `json:"node_id" yaml:"node_id"`
+	CPU             float64 `json:"cpu" yaml:"cpu"`                           // CPU 使用率（%）
+	MemoryUsage     float64 `json:"memory_usage" yaml:"memory_usage"`         // 内存使用率（%，相对于 tidb_server_memory_limit 或主机内存）
+	ConnectionCount int     `json:"connection_count" yaml:"connection_count"` // 当前连接数
+	QPS             float64 `json:"qps" yaml:"qps"`
+	DurationP99     float64 `json:"duration_p99" yaml:"duration_p99"` // 查询耗时 P99（毫秒）
+}
+
+// calculateTiDBServerStatistics 计算 TiDB Server 节点的统计信息
+func (monitor *TiDBMonitor) calculateTiDBServerStatistics() {
+	monitor.TiDBServerCount = len(monitor.TiDBServers)
+	if len(monitor.TiDBServers) == 0 {
+		return
+	}
+
+	var totalConnections, totalQPS, totalCPU float64
+	var maxConnections, maxQPS, maxCPU, maxMemoryUsage, maxDurationP99 float64
+	var connectionNode, qpsNode, cpuNode, memoryNode, durationNode string
+
+	for _, node := range monitor.TiDBServers {
+		connections := float64(node.ConnectionCount)
+		totalConnections += connections
+		if connections > maxConnections {
+			maxConnections = connections
+			connectionNode = node.NodeID
+		}
+
+		totalQPS += node.QPS
+		if node.QPS > maxQPS {
+			maxQPS = node.QPS
+			qpsNode = node.NodeID
+		}
+
+		totalCPU += node.CPU
+		if node.CPU > maxCPU {
+			maxCPU = node.CPU
+			cpuNode = node.NodeID
+		}
+
+		if node.MemoryUsage > maxMemoryUsage {
+			maxMemoryUsage = node.MemoryUsage
+			memoryNode = node.NodeID
+		}
+
+		if node.DurationP99 > maxDurationP99 {
+			maxDurationP99 = node.DurationP99
+			durationNode = node.NodeID
+		}
+	}
+
+	count := float64(len(monitor.TiDBServers))
+	monitor.MaxTiDBServerConnections = maxConnections
+	monitor.AvgTiDBServerConnections = totalConnections / count
+	monitor.TiDBServerConnectionNode = connectionNode
+
+	monitor.MaxTiDBServerQPS = maxQPS
+	monitor.AvgTiDBServerQPS = totalQPS / count
+	monitor.TiDBServerQPSNode = qpsNode
+
+	monitor.MaxTiDBServerCPU = maxCPU
+	monitor.AvgTiDBServerCPU = totalCPU / count
+	monitor.TiDBServerCPUNode = cpuNode
+
+	monitor.MaxTiDBServerMemoryUsage = maxMemoryUsage
+	monitor.TiDBServerMemoryNode = memoryNode
+	monitor.MaxTiDBServerDurationP99 = maxDurationP99
+	monitor.TiDBServerDurationNode = durationNode
+}
@@ -0,0 +1,80 @@
+package main
+
+// unifiedReadPoolDefaultSize TiKV readpool.unified.max-thread-count 的默认值：max(4, CPU 核数 × 0.8)
+func unifiedReadPoolDefaultSize(cores int) int {
+	size := cores * 8 / 10
+	if size < 4 {
+		size = 4
+	}
+	return size
+}
+
+// effectiveThreads 线程池实际可用的线程数：线程池大小，但不超过节点 CPU 核数（未提供核数时不限制）
+func (node *TiKVNode) effectiveThreads(poolSize int) float64 {
+	if node.CPUCores > 0 && poolSize > node.CPUCores {
+		return float64(node.CPUCores)
+	}
+	return float64(poolSize)
+}
+
+// RaftstoreUtilization Raftstore 线程池利用率（0-1）：RaftstoreCPU / (store-pool-size × 100%)
+func (node *TiKVNode) RaftstoreUtilization() float64 {
+	return node.RaftstoreCPU / (node.effectiveThreads(raftstoreThreadPool.sizeOf(node)) * 100)
+}
+
+// CoprocessorUtilization Coprocessor（unified read pool）利用率（0-1）：CoprocessorCPU / (unified read pool 大小 × 100%)
+func (node *TiKVNode) CoprocessorUtilization() float64 {
+	return node.CoprocessorCPU / (node.effectiveThreads(unifiedReadThreadPool.sizeOf(node)) * 100)
+}
+
+// raftstoreLoad 参与热点统计的 Raftstore 负载：默认为原始 CPU（%），NormalizeCPU 时为线程池利用率（%）
+func (monitor *TiDBMonitor) raftstoreLoad(node *TiKVNode) float64 {
+	if monitor.NormalizeCPU {
+		return node.RaftstoreUtilization() * 100
+	}
+	return node.RaftstoreCPU
+}
+
+// coprocessorLoad 参与热点统计的 Coprocessor 负载：默认为原始 CPU（%），NormalizeCPU 时为线程池利用率（%）
+func (monitor *TiDBMonitor) coprocessorLoad(node *TiKVNode) float64 {
+	if monitor.NormalizeCPU {
+		return node.CoprocessorUtilization() * 100
+	}
+	return node.CoprocessorCPU
+}
+
+// calculateCapacityStatistics 计算按容量归一化的利用率，并判断节点硬件或线程池配置是否一致
+func (monitor *TiDBMonitor) calculateCapacityStatistics() {
+	monitor.MaxRaftstoreUtilization, monitor.AvgRaftstoreUtilization, monitor.RaftstoreUtilizationNode = 0, 0, ""
+	monitor.MaxCoprocessorUtilization, monitor.AvgCoprocessorUtilization, monitor.CoprocessorUtilizationNode = 0, 0, ""
+	monitor.HeterogeneousHardware = false
+	if len(monitor.TiKVNodes) == 0 {
+		return
+	}
+
+	first := monitor.TiKVNodes[0]
+	var totalRaftstore, totalCoprocessor float64
+	for _, node := range monitor.TiKVNodes {
+		raftstore := node.RaftstoreUtilization()
+		totalRaftstore += raftstore
+		if monitor.RaftstoreUtilizationNode == "" || raftstore > monitor.MaxRaftstoreUtilization {
+			monitor.MaxRaftstoreUtilization = raftstore
+			monitor.RaftstoreUtilizationNode = node.NodeID
+		}
+
+		coprocessor := node.CoprocessorUtilization()
+		totalCoprocessor += coprocessor
+		if monitor.CoprocessorUtilizationNode == "" || coprocessor > monitor.MaxCoprocessorUtilization {
+			monitor.MaxCoprocessorUtilization = coprocessor
+			monitor.CoprocessorUtilizationNode = node.NodeID
+		}
+
+		if node.CPUCores != first.CPUCores ||
+			raftstoreThreadPool.sizeOf(node) != raftstoreThreadPool.sizeOf(first) ||
+			unifiedReadThreadPool.sizeOf(node) != unifiedReadThreadPool.sizeOf(first) {
+			monitor.HeterogeneousHardware = true
+		}
+	}
+	monitor.AvgRaftstoreUtilization = totalRaftstore / float64(len(monitor.TiKVNodes))
+	monitor.AvgCoprocessorUtilization = totalCoprocessor / float64(len(monitor.TiKVNodes))
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestCapacityNormalizedUtilization(t *testing.T) {
+	nodes := func() []*TiKVNode {
+		return []*TiKVNode{
+			{NodeID: "tikv-1", CPUCores: 16, StorePoolSize: 4, RaftstoreCPU: 200, CoprocessorCPU: 600},
+			{NodeID: "tikv-2", CPUCores: 8, RaftstoreCPU: 100, CoprocessorCPU: 300},
+			{NodeID: "tikv-3", CPUCores: 8, RaftstoreCPU: 100, CoprocessorCPU: 300},
+		}
+	}
+
+	node := nodes()[0]
+	if node.RaftstoreUtilization() != 0.5 || node.CoprocessorUtilization() != 0.5 {
+		t.Errorf("16 核节点利用率不符: raftstore=%.3f coprocessor=%.3f", node.RaftstoreUtilization(), node.CoprocessorUtilization())
+	}
+	small := &TiKVNode{CPUCores: 2, UnifiedReadPoolSize: 8, CoprocessorCPU: 100}
+	if small.CoprocessorUtilization() != 0.5 {
+		t.Errorf("线程池大小应受 CPU 核数限制，实际利用率 %.3f", small.CoprocessorUtilization())
+	}
+	if unifiedReadPoolDefaultSize(2) != 4 || unifiedReadPoolDefaultSize(16) != 12 {
+		t.Errorf("unified read pool 默认大小不符")
+	}
+
+	raw := &TiDBMonitor{TiKVNodes: nodes()}
+	raw.CalculateStatistics()
+	if raw.MaxRaftstoreCPU != 200 || raw.WriteHotspotNode != "tikv-1" || !raw.HeterogeneousHardware {
+		t.Errorf("未归一化时应使用原始 CPU: max=%.2f node=%s", raw.MaxRaftstoreCPU, raw.WriteHotspotNode)
+	}
+	if raw.MaxRaftstoreUtilization != 0.5 || raw.AvgRaftstoreUtilization != 0.5 {
+		t.Errorf("利用率统计不符: max=%.3f avg=%.3f", raw.MaxRaftstoreUtilization, raw.AvgRaftstoreUtilization)
+	}
+
+	normalized := &TiDBMonitor{TiKVNodes: nodes(), NormalizeCPU: true}
+	normalized.CalculateStatistics()
+	if normalized.MaxRaftstoreCPU != 50 || normalized.AvgRaftstoreCPU != 50 ||
+		normalized.MaxCoprocessorCPU != 50 || normalized.AvgCoprocessorCPU != 50 {
+		t.Errorf("归一化后各节点利用率应一致: raftstore max=%.2f avg=%.2f coprocessor max=%.2f avg=%.2f",
+			normalized.MaxRaftstoreCPU, normalized.AvgRaftstoreCPU, normalized.MaxCoprocessorCPU, normalized.AvgCoprocessorCPU)
+	}
+
+	uniform := &TiDBMonitor{TiKVNodes: nodes()[1:]}
+	uniform.CalculateStatistics()
+	if uniform.HeterogeneousHardware {
+		t.Errorf("硬件和线程池配置一致的节点不应视为混合硬件")
+	}
+}
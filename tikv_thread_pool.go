@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// threadPoolTargetUtilization 建议的线程池大小使饱和节点的利用率回落到该值以下
+const threadPoolTargetUtilization = 0.6
+
+// ThreadPoolStats 某个 TiKV 线程池的统计信息（需要在 Go 代码中计算）
+// 利用率 = CPU / (有效线程数 × 100%)，有效线程数为线程池大小，但不超过节点 CPU 核数（见 effectiveThreads）
+// TiDBMonitor 上的线程池字段汇总利用率最高的节点，供规则判断是否存在饱和节点；Nodes 为每个节点各自的统计信息
+type ThreadPoolStats struct {
+	Pool                string  // 线程池名称
+	ConfigKey           string  // 控制线程池大小的 TiKV 配置项
+	Node                string  // 利用率最高的节点
+	CPU                 float64 // 该节点的线程池 CPU（%，100% 为一个核）
+	PoolSize            int     // 该节点的线程池大小
+	Utilization         float64 // 该节点的线程池利用率（0-1）
+	RecommendedPoolSize int     // 使利用率回落到 threadPoolTargetUtilization 的线程池大小，不超过节点 CPU 核数
+
+	Nodes []*ThreadPoolStats // 每个节点的统计信息，顺序与 TiKVNodes 一致
+
+	// 检测结果
+	Saturated bool
+}
+
+// MarkSaturated 将利用率达到 threshold 的节点标记为饱和，由规则调用
+func (stats *ThreadPoolStats) MarkSaturated(threshold float64) {
+	stats.Saturated = true
+	for _, node := range stats.Nodes {
+		if node.Utilization >= threshold {
+			node.Saturated = true
+		}
+	}
+}
+
+// SaturatedNodes 被标记为饱和的节点，用逗号分隔
+func (stats *ThreadPoolStats) SaturatedNodes() string {
+	var nodes []string
+	for _, node := range stats.Nodes {
+		if node.Saturated {
+			nodes = append(nodes, node.Node)
+		}
+	}
+	return strings.Join(nodes, ", ")
+}
+
+// ConfigRecommendation 配置变更建议
+type ConfigRecommendation struct {
+	Node        string `json:"node"`
+	ConfigKey   string `json:"config_key"`
+	Current     int    `json:"current"`
+	Recommended int    `json:"recommended"`
+	Reason      string `json:"reason"`
+}
+
+// String 配置建议的文本形式
+func (recommendation ConfigRecommendation) String() string {
+	return fmt.Sprintf("%s: %s %d -> %d（%s）", recommendation.Node, recommendation.ConfigKey,
+		recommendation.Current, recommendation.Recommended, recommendation.Reason)
+}
+
+// tikvThreadPool TiKV 线程池定义：CPU 指标、线程池大小及其默认值
+type tikvThreadPool struct {
+	name        string
+	configKey   string
+	defaultSize int
+	// defaultSizeForCores 默认值与 CPU 核数相关时使用（节点提供了 CPUCores）
+	defaultSizeForCores func(cores int) int
+	cpu                 func(node *TiKVNode) float64
+	size                func(node *TiKVNode) int
+	stats               func(monitor *TiDBMonitor) **ThreadPoolStats
+}
+
+// sizeOf 节点上的线程池大小，未配置时使用 TiKV 默认值
+func (pool tikvThreadPool) sizeOf(node *TiKVNode) int {
+	if size := pool.size(node); size > 0 {
+		return size
+	}
+	if pool.defaultSizeForCores != nil && node.CPUCores > 0 {
+		return pool.defaultSizeForCores(node.CPUCores)
+	}
+	return pool.defaultSize
+}
+
+// raftstoreThreadPool 和 unifiedReadThreadPool 也用于 Raftstore / Coprocessor CPU 的归一化
+var (
+	raftstoreThreadPool = tikvThreadPool{
+		name: "raftstore", configKey: "raftstore.store-pool-size", defaultSize: 2,
+		cpu:   func(node *TiKVNode) float64 { return node.RaftstoreCPU },
+		size:  func(node *TiKVNode) int { return node.StorePoolSize },
+		stats: func(monitor *TiDBMonitor) **ThreadPoolStats { return &monitor.RaftstorePool },
+	}
+	unifiedReadThreadPool = tikvThreadPool{
+		name: "unified-read-pool", configKey: "readpool.unified.max-thread-count", defaultSize: 4,
+		defaultSizeForCores: unifiedReadPoolDefaultSize,
+		cpu:                 func(node *TiKVNode) float64 { return node.UnifiedReadPoolCPU },
+		size:                func(node *TiKVNode) int { return node.UnifiedReadPoolSize },
+		stats:               func(monitor *TiDBMonitor) **ThreadPoolStats { return &monitor.UnifiedReadPool },
+	}
+)
+
+// tikvThreadPools 参与饱和度检测的 TiKV 线程池，节点未提供线程池大小时使用 TiKV 默认值
+var tikvThreadPools = []tikvThreadPool{
+	raftstoreThreadPool,
+	{
+		name: "apply", configKey: "raftstore.apply-pool-size", defaultSize: 2,
+		cpu:   func(node *TiKVNode) float64 { return node.ApplyCPU },
+		size:  func(node *TiKVNode) int { return node.ApplyPoolSize },
+		stats: func(monitor *TiDBMonitor) **ThreadPoolStats { return &monitor.ApplyPool },
+	},
+	{
+		name: "scheduler-worker", configKey: "storage.scheduler-worker-pool-size", defaultSize: 4,
+		cpu:   func(node *TiKVNode) float64 { return node.SchedulerWorkerCPU },
+		size:  func(node *TiKVNode) int { return node.SchedulerWorkerPoolSize },
+		stats: func(monitor *TiDBMonitor) **ThreadPoolStats { return &monitor.SchedulerWorkerPool },
+	},
+	{
+		name: "grpc-poll", configKey: "server.grpc-concurrency", defaultSize: 5,
+		cpu:   func(node *TiKVNode) float64 { return node.GRPCPollCPU },
+		size:  func(node *TiKVNode) int { return node.GRPCConcurrency },
+		stats: func(monitor *TiDBMonitor) **ThreadPoolStats { return &monitor.GRPCPool },
+	},
+	unifiedReadThreadPool,
+	{
+		name: "storage-read-pool", configKey: "readpool.storage.normal-concurrency", defaultSize: 4,
+		cpu:   func(node *TiKVNode) float64 { return node.StorageReadPoolCPU },
+		size:  func(node *TiKVNode) int { return node.StorageReadPoolSize },
+		stats: func(monitor *TiDBMonitor) **ThreadPoolStats { return &monitor.StorageReadPool },
+	},
+}
+
+// calculateThreadPoolStatistics 计算每个线程池在每个节点上的利用率，并汇总利用率最高的节点
+func (monitor *TiDBMonitor) calculateThreadPoolStatistics() {
+	for _, pool := range tikvThreadPools {
+		stats := &ThreadPoolStats{Pool: pool.name, ConfigKey: pool.configKey}
+		*pool.stats(monitor) = stats
+
+		for _, node := range monitor.TiKVNodes {
+			size := pool.sizeOf(node)
+			nodeStats := &ThreadPoolStats{
+				Pool:        pool.name,
+				ConfigKey:   pool.configKey,
+				Node:        node.NodeID,
+				CPU:         pool.cpu(node),
+				PoolSize:    size,
+				Utilization: pool.cpu(node) / (node.effectiveThreads(size) * 100),
+			}
+			nodeStats.RecommendedPoolSize = recommendPoolSize(nodeStats.CPU, nodeStats.PoolSize, node.CPUCores)
+			stats.Nodes = append(stats.Nodes, nodeStats)
+
+			if stats.Node == "" || nodeStats.Utilization > stats.Utilization {
+				stats.Node = nodeStats.Node
+				stats.CPU = nodeStats.CPU
+				stats.PoolSize = nodeStats.PoolSize
+				stats.Utilization = nodeStats.Utilization
+				stats.RecommendedPoolSize = nodeStats.RecommendedPoolSize
+			}
+		}
+	}
+}
+
+// recommendPoolSize 使利用率回落到 threadPoolTargetUtilization 以下的线程池大小，至少比当前大 1；
+// cpuCores 不为 0 时不超过核数，此时线程池已经用满所有核，需要扩容节点而不是调大线程池
+func recommendPoolSize(cpu float64, poolSize int, cpuCores int) int {
+	recommended := int(math.Ceil(cpu / (threadPoolTargetUtilization * 100)))
+	if recommended <= poolSize {
+		recommended = poolSize + 1
+	}
+	if cpuCores > 0 && recommended > cpuCores {
+		recommended = cpuCores
+	}
+	return recommended
+}
+
+// ThreadPools 所有线程池的统计信息，顺序与 tikvThreadPools 一致，未计算统计信息时为空
+func (monitor *TiDBMonitor) ThreadPools() []*ThreadPoolStats {
+	var pools []*ThreadPoolStats
+	for _, pool := range tikvThreadPools {
+		if stats := *pool.stats(monitor); stats != nil {
+			pools = append(pools, stats)
+		}
+	}
+	return pools
+}
+
+// cloneThreadPools 深拷贝线程池统计信息
+func (monitor *TiDBMonitor) cloneThreadPools(cloned *TiDBMonitor) {
+	for _, pool := range tikvThreadPools {
+		if stats := *pool.stats(monitor); stats != nil {
+			copied := *stats
+			copied.Nodes = make([]*ThreadPoolStats, len(stats.Nodes))
+			for i, node := range stats.Nodes {
+				copiedNode := *node
+				copied.Nodes[i] = &copiedNode
+			}
+			*pool.stats(cloned) = &copied
+		}
+	}
+}
+
+// SaturatedThreadPools 规则检测到的饱和节点/线程池组合，按线程池、节点顺序排列
+func (monitor *TiDBMonitor) SaturatedThreadPools() []*ThreadPoolStats {
+	var saturated []*ThreadPoolStats
+	for _, stats := range monitor.ThreadPools() {
+		for _, node := range stats.Nodes {
+			if node.Saturated {
+				saturated = append(saturated, node)
+			}
+		}
+	}
+	return saturated
+}
+
+// ConfigRecommendations 根据规则检测到的饱和节点/线程池组合生成配置变更建议，每个饱和节点一条
+func (monitor *TiDBMonitor) ConfigRecommendations() []ConfigRecommendation {
+	var recommendations []ConfigRecommendation
+	for _, stats := range monitor.SaturatedThreadPools() {
+		recommendations = append(recommendations, ConfigRecommendation{
+			Node:        stats.Node,
+			ConfigKey:   stats.ConfigKey,
+			Current:     stats.PoolSize,
+			Recommended: stats.RecommendedPoolSize,
+			Reason:      fmt.Sprintf("%s 线程池利用率 %.0f%%", stats.Pool, stats.Utilization*100),
+		})
+	}
+	return recommendations
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThreadPoolConfigRecommendations(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack("tikv-thread-pool", nil, "TiKVThreadPool", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+
+	monitor := &TiDBMonitor{
+		CheckTiKVThreadPools: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 120, StorePoolSize: 2},
+			{NodeID: "tikv-2", RaftstoreCPU: 290, StorePoolSize: 3},
+		},
+	}
+	monitor.CalculateStatistics()
+	cloned := monitor.Clone()
+
+	if err := executor.Execute(monitor); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+
+	recommendations := monitor.ConfigRecommendations()
+	if len(recommendations) != 1 {
+		t.Fatalf("期望 1 条配置建议，实际 %v", recommendations)
+	}
+	want := ConfigRecommendation{Node: "tikv-2", ConfigKey: "raftstore.store-pool-size", Current: 3, Recommended: 5, Reason: "raftstore 线程池利用率 97%"}
+	if recommendations[0] != want {
+		t.Errorf("配置建议期望 %v，实际 %v", want, recommendations[0])
+	}
+
+	findings := monitor.Findings()
+	if len(findings) != 1 || findings[0].Key() != "tikv_thread_pool_saturation@tikv-2/raftstore" {
+		t.Errorf("结论不符: %v", findings)
+	}
+
+	// 线程池大于 CPU 核数时按核数计算利用率，建议值也不超过核数
+	capped := &TiDBMonitor{
+		CheckTiKVThreadPools: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 350, StorePoolSize: 8, CPUCores: 4},
+			{NodeID: "tikv-2", RaftstoreCPU: 150, StorePoolSize: 2, CPUCores: 16},
+		},
+	}
+	capped.CalculateStatistics()
+	if err := executor.Execute(capped); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+	if pool := capped.RaftstorePool; pool.Node != "tikv-1" || !pool.Saturated || pool.Utilization != 0.875 || pool.RecommendedPoolSize != 4 {
+		t.Errorf("按核数计算的线程池统计不符: %+v", pool)
+	}
+
+	// 深拷贝的线程池统计不受规则执行影响
+	if cloned.RaftstorePool == monitor.RaftstorePool || cloned.RaftstorePool.Saturated || cloned.RaftstorePool.Nodes[1].Saturated {
+		t.Errorf("Clone 应深拷贝线程池统计信息")
+	}
+}
+
+func TestThreadPoolSaturationEveryNode(t *testing.T) {
+	executor, err := NewTiDBRuleExecutorWithPack("tikv-thread-pool", nil, "TiKVThreadPool", "1.0.0")
+	if err != nil {
+		t.Fatalf("初始化规则执行器失败: %v", err)
+	}
+
+	// tikv-1 和 tikv-3 的 raftstore 线程池都达到饱和阈值，tikv-2 未饱和
+	monitor := &TiDBMonitor{
+		CheckTiKVThreadPools: true,
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 170, StorePoolSize: 2},
+			{NodeID: "tikv-2", RaftstoreCPU: 120, StorePoolSize: 2},
+			{NodeID: "tikv-3", RaftstoreCPU: 290, StorePoolSize: 3},
+		},
+	}
+	monitor.CalculateStatistics()
+
+	if err := executor.Execute(monitor); err != nil {
+		t.Fatalf("执行规则失败: %v", err)
+	}
+
+	if monitor.RaftstorePool.Node != "tikv-3" || monitor.RaftstorePool.SaturatedNodes() != "tikv-1, tikv-3" {
+		t.Errorf("饱和节点不符: 最高 %s，饱和 %q", monitor.RaftstorePool.Node, monitor.RaftstorePool.SaturatedNodes())
+	}
+
+	recommendations := monitor.ConfigRecommendations()
+	want := []ConfigRecommendation{
+		{Node: "tikv-1", ConfigKey: "raftstore.store-pool-size", Current: 2, Recommended: 3, Reason: "raftstore 线程池利用率 85%"},
+		{Node: "tikv-3", ConfigKey: "raftstore.store-pool-size", Current: 3, Recommended: 5, Reason: "raftstore 线程池利用率 97%"},
+	}
+	if len(recommendations) != len(want) {
+		t.Fatalf("期望 %d 条配置建议，实际 %v", len(want), recommendations)
+	}
+	for i := range want {
+		if recommendations[i] != want[i] {
+			t.Errorf("配置建议 %d 期望 %v，实际 %v", i, want[i], recommendations[i])
+		}
+	}
+
+	var keys []string
+	for _, finding := range monitor.Findings() {
+		keys = append(keys, finding.Key())
+	}
+	if strings.Join(keys, ",") != "tikv_thread_pool_saturation@tikv-1/raftstore,tikv_thread_pool_saturation@tikv-3/raftstore" {
+		t.Errorf("结论不符: %v", keys)
+	}
+}
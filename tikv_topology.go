@@ -0,0 +1,174 @@
+package main
+
+import (
+	"net"
+	"sort"
+)
+
+// PD store label 的常用键
+const (
+	LabelZone = "zone"
+	LabelHost = "host"
+)
+
+// TopologyGroup 按 store label（zone / host）分组的 TiKV 节点统计信息（需要在 Go 代码中计算）
+type TopologyGroup struct {
+	Label string   // 分组依据的 label 键，如 zone
+	Value string   // label 值，如 az-1
+	Nodes []string // 组内节点 ID
+
+	AvgRaftstoreCPU   float64
+	MaxRaftstoreCPU   float64
+	MinRaftstoreCPU   float64
+	AvgCoprocessorCPU float64
+	MaxCoprocessorCPU float64
+	MinCoprocessorCPU float64
+}
+
+// Label 返回 PD store label 的值，未设置时为空
+func (node *TiKVNode) Label(key string) string {
+	return node.Labels[key]
+}
+
+// Zone 节点所在的可用区（zone label）
+func (node *TiKVNode) Zone() string {
+	return node.Label(LabelZone)
+}
+
+// Host 节点所在的主机：优先使用 host label，否则取 Address 中的主机部分
+func (node *TiKVNode) Host() string {
+	if host := node.Label(LabelHost); host != "" {
+		return host
+	}
+	if host, _, err := net.SplitHostPort(node.Address); err == nil {
+		return host
+	}
+	return node.Address
+}
+
+// groupTiKVNodes 按 label 值对节点分组，未设置该 label 的节点不参与分组，结果按 label 值排序
+// （NormalizeCPU 时 CPU 统计为线程池利用率）
+func (monitor *TiDBMonitor) groupTiKVNodes(label string, value func(node *TiKVNode) string) []*TopologyGroup {
+	byValue := make(map[string][]*TiKVNode)
+	for _, node := range monitor.TiKVNodes {
+		if v := value(node); v != "" {
+			byValue[v] = append(byValue[v], node)
+		}
+	}
+
+	groups := make([]*TopologyGroup, 0, len(byValue))
+	for v, members := range byValue {
+		group := &TopologyGroup{Label: label, Value: v}
+		var totalRaftstoreCPU, totalCoprocessorCPU float64
+		for i, node := range members {
+			raftstoreCPU, coprocessorCPU := monitor.raftstoreLoad(node), monitor.coprocessorLoad(node)
+			group.Nodes = append(group.Nodes, node.NodeID)
+			totalRaftstoreCPU += raftstoreCPU
+			totalCoprocessorCPU += coprocessorCPU
+			if i == 0 || raftstoreCPU > group.MaxRaftstoreCPU {
+				group.MaxRaftstoreCPU = raftstoreCPU
+			}
+			if i == 0 || raftstoreCPU < group.MinRaftstoreCPU {
+				group.MinRaftstoreCPU = raftstoreCPU
+			}
+			if i == 0 || coprocessorCPU > group.MaxCoprocessorCPU {
+				group.MaxCoprocessorCPU = coprocessorCPU
+			}
+			if i == 0 || coprocessorCPU < group.MinCoprocessorCPU {
+				group.MinCoprocessorCPU = coprocessorCPU
+			}
+		}
+		group.AvgRaftstoreCPU = totalRaftstoreCPU / float64(len(members))
+		group.AvgCoprocessorCPU = totalCoprocessorCPU / float64(len(members))
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Value < groups[j].Value
+	})
+	return groups
+}
+
+// calculateTopologyStatistics 按 zone 和 host 计算统计信息，找出 Raftstore / Coprocessor CPU 平均值最高的 zone，
+// 写热点节点与同 zone 其他节点的对比，以及写热点节点所在主机上的 TiKV 实例数，
+// 用于区分单个热点 store、同主机实例争用和整个可用区的不均衡
+func (monitor *TiDBMonitor) calculateTopologyStatistics() {
+	monitor.Zones = monitor.groupTiKVNodes(LabelZone, (*TiKVNode).Zone)
+	monitor.Hosts = monitor.groupTiKVNodes(LabelHost, (*TiKVNode).Host)
+	monitor.ZoneCount = len(monitor.Zones)
+
+	monitor.HotWriteZone, monitor.HotWriteZoneNodeCount = "", 0
+	monitor.HotWriteZoneAvgRaftstoreCPU, monitor.HotWriteZoneMinRaftstoreCPU, monitor.OtherZonesAvgRaftstoreCPU = 0, 0, 0
+	monitor.HotReadZone, monitor.HotReadZoneNodeCount = "", 0
+	monitor.HotReadZoneAvgCoprocessorCPU, monitor.HotReadZoneMinCoprocessorCPU, monitor.OtherZonesAvgCoprocessorCPU = 0, 0, 0
+	monitor.WriteHotspotZone, monitor.WriteHotspotZonePeerRaftstoreCPU = "", 0
+	monitor.WriteHotspotHost, monitor.WriteHotspotHostStoreCount = "", 0
+
+	var hotWriteZone, hotReadZone *TopologyGroup
+	for _, zone := range monitor.Zones {
+		if hotWriteZone == nil || zone.AvgRaftstoreCPU > hotWriteZone.AvgRaftstoreCPU {
+			hotWriteZone = zone
+		}
+		if hotReadZone == nil || zone.AvgCoprocessorCPU > hotReadZone.AvgCoprocessorCPU {
+			hotReadZone = zone
+		}
+	}
+	if hotWriteZone != nil {
+		monitor.HotWriteZone = hotWriteZone.Value
+		monitor.HotWriteZoneNodeCount = len(hotWriteZone.Nodes)
+		monitor.HotWriteZoneAvgRaftstoreCPU = hotWriteZone.AvgRaftstoreCPU
+		monitor.HotWriteZoneMinRaftstoreCPU = hotWriteZone.MinRaftstoreCPU
+		monitor.OtherZonesAvgRaftstoreCPU = monitor.averageOutsideZone(hotWriteZone.Value, monitor.raftstoreLoad)
+	}
+	if hotReadZone != nil {
+		monitor.HotReadZone = hotReadZone.Value
+		monitor.HotReadZoneNodeCount = len(hotReadZone.Nodes)
+		monitor.HotReadZoneAvgCoprocessorCPU = hotReadZone.AvgCoprocessorCPU
+		monitor.HotReadZoneMinCoprocessorCPU = hotReadZone.MinCoprocessorCPU
+		monitor.OtherZonesAvgCoprocessorCPU = monitor.averageOutsideZone(hotReadZone.Value, monitor.coprocessorLoad)
+	}
+
+	for _, node := range monitor.TiKVNodes {
+		if node.NodeID != monitor.WriteHotspotNode {
+			continue
+		}
+		monitor.WriteHotspotZone = node.Zone()
+		monitor.WriteHotspotHost = node.Host()
+		var peerCPU float64
+		var peers int
+		for _, peer := range monitor.TiKVNodes {
+			if peer == node {
+				continue
+			}
+			if monitor.WriteHotspotZone != "" && peer.Zone() == monitor.WriteHotspotZone {
+				peerCPU += monitor.raftstoreLoad(peer)
+				peers++
+			}
+		}
+		if peers > 0 {
+			monitor.WriteHotspotZonePeerRaftstoreCPU = peerCPU / float64(peers)
+		}
+		for _, host := range monitor.Hosts {
+			if host.Value == monitor.WriteHotspotHost {
+				monitor.WriteHotspotHostStoreCount = len(host.Nodes)
+			}
+		}
+		break
+	}
+}
+
+// averageOutsideZone 不在指定 zone 中（且设置了 zone label）的节点的平均值
+func (monitor *TiDBMonitor) averageOutsideZone(zone string, metric func(node *TiKVNode) float64) float64 {
+	var total float64
+	var count int
+	for _, node := range monitor.TiKVNodes {
+		if node.Zone() != "" && node.Zone() != zone {
+			total += metric(node)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
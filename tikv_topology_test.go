@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopologyStatistics(t *testing.T) {
+	monitor := &TiDBMonitor{
+		TiKVNodes: []*TiKVNode{
+			{NodeID: "tikv-1", RaftstoreCPU: 90, CoprocessorCPU: 10, Address: "10.0.1.1:20160", Labels: map[string]string{LabelZone: "az-1"}},
+			{NodeID: "tikv-2", RaftstoreCPU: 30, CoprocessorCPU: 30, Address: "10.0.1.1:20161", Labels: map[string]string{LabelZone: "az-1"}},
+			{NodeID: "tikv-3", RaftstoreCPU: 20, CoprocessorCPU: 50, Labels: map[string]string{LabelZone: "az-2", LabelHost: "h3"}},
+			{NodeID: "tikv-4", RaftstoreCPU: 40, CoprocessorCPU: 70},
+		},
+	}
+	monitor.CalculateStatistics()
+
+	if monitor.ZoneCount != 2 || len(monitor.Hosts) != 2 {
+		t.Fatalf("期望 2 个 zone、2 个 host，实际 %d、%d", monitor.ZoneCount, len(monitor.Hosts))
+	}
+	az1 := monitor.Zones[0]
+	if az1.Value != "az-1" || !reflect.DeepEqual(az1.Nodes, []string{"tikv-1", "tikv-2"}) ||
+		az1.AvgRaftstoreCPU != 60 || az1.MinRaftstoreCPU != 30 || az1.MaxCoprocessorCPU != 30 {
+		t.Errorf("az-1 统计不符: %+v", az1)
+	}
+	if host := monitor.Hosts[0]; host.Value != "10.0.1.1" || len(host.Nodes) != 2 {
+		t.Errorf("未设置 host label 时应按 Address 的主机部分分组: %+v", host)
+	}
+
+	if monitor.HotWriteZone != "az-1" || monitor.OtherZonesAvgRaftstoreCPU != 20 {
+		t.Errorf("写入最高的 zone 不符: %s, 其他 zone 平均值 %.2f", monitor.HotWriteZone, monitor.OtherZonesAvgRaftstoreCPU)
+	}
+	if monitor.HotReadZone != "az-2" || monitor.OtherZonesAvgCoprocessorCPU != 20 {
+		t.Errorf("读取最高的 zone 不符: %s, 其他 zone 平均值 %.2f", monitor.HotReadZone, monitor.OtherZonesAvgCoprocessorCPU)
+	}
+	if monitor.WriteHotspotZone != "az-1" || monitor.WriteHotspotHost != "10.0.1.1" ||
+		monitor.WriteHotspotHostStoreCount != 2 || monitor.WriteHotspotZonePeerRaftstoreCPU != 30 {
+		t.Errorf("写热点节点的拓扑信息不符: zone=%s host=%s stores=%d peer=%.2f", monitor.WriteHotspotZone,
+			monitor.WriteHotspotHost, monitor.WriteHotspotHostStoreCount, monitor.WriteHotspotZonePeerRaftstoreCPU)
+	}
+
+	cloned := monitor.Clone()
+	cloned.TiKVNodes[0].Labels[LabelZone] = "az-9"
+	if monitor.TiKVNodes[0].Zone() != "az-1" {
+		t.Errorf("Clone 应深拷贝 labels")
+	}
+}